@@ -14,8 +14,8 @@ func NewNodeSet(size int) NodeSet {
 	return NodeSet(make([]uint32, (size+31)/32))
 }
 
-func (set NodeSet) Offset(node graph.Node) (bucket, bit uint32) {
-	bucket = uint32(node >> bucket_bits)
+func (set NodeSet) Offset(node graph.Node) (bucket graph.Node, bit uint32) {
+	bucket = node >> bucket_bits
 	bit = uint32(1 << (node & bucket_mask))
 	return bucket, bit
 }
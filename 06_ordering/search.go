@@ -2,7 +2,7 @@ package search
 
 import (
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/shawnsmithdev/zermelo/zuint32"
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
 )
 
 func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
@@ -22,16 +22,32 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
 	levelNumber := 2
 
 	for len(currentLevel) > 0 {
+		if tracing.Enabled {
+			tracing.BeginLevel(levelNumber-1, len(currentLevel), 1)
+		}
+
+		span := tracing.Begin(0, tracing.Expand)
 		for _, node := range currentLevel {
-			for _, neighbor := range g.Neighbors(node) {
+			neighbors := g.Neighbors(node)
+			if tracing.Enabled {
+				tracing.Count(0, int64(len(neighbors)), 0)
+			}
+			for _, neighbor := range neighbors {
 				if !visited.Contains(neighbor) {
 					visited.Add(neighbor)
 					nextLevel = append(nextLevel, neighbor)
 				}
 			}
 		}
+		span.End()
+
+		if tracing.Enabled {
+			tracing.Count(0, 0, int64(len(nextLevel)))
+		}
 
-		zuint32.SortBYOB(nextLevel, currentLevel[:cap(currentLevel)])
+		span = tracing.Begin(0, tracing.Sort)
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
+		span.End()
 
 		for _, neighbor := range nextLevel {
 			level[neighbor] = levelNumber
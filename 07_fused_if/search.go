@@ -2,7 +2,6 @@ package search
 
 import (
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/shawnsmithdev/zermelo/zuint32"
 )
 
 func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
@@ -30,7 +29,7 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
 			}
 		}
 
-		zuint32.SortBYOB(nextLevel, currentLevel[:cap(currentLevel)])
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
 
 		for _, neighbor := range nextLevel {
 			level[neighbor] = levelNumber
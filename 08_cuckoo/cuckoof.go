@@ -3,6 +3,8 @@ package search
 
 import (
 	"math/rand"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
 )
 
 // CF is a cuckoo filter
@@ -30,8 +32,8 @@ func NewCuckoof(size int) *CF {
 }
 
 // Insert adds an element to the filter and returns if the insertion was successful.
-func (cf *CF) Insert(x uint32) bool {
-	h := x
+func (cf *CF) Insert(x graph.Node) bool {
+	h := uint64(x)
 
 	i1 := uint32(h) % uint32(len(cf.t))
 
@@ -68,8 +70,8 @@ func (cf *CF) Insert(x uint32) bool {
 }
 
 // Lookup queries the cuckoo filter for an item
-func (cf *CF) Lookup(x uint32) bool {
-	h := x
+func (cf *CF) Lookup(x graph.Node) bool {
+	h := uint64(x)
 
 	i1 := uint32(h) % uint32(len(cf.t))
 
@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/shawnsmithdev/zermelo/zuint32"
 )
 
 func process(ch chan<- []graph.Node, g *graph.Graph, block []graph.Node, visited *NodeSet) {
@@ -27,6 +26,9 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
 	}
 
 	np := runtime.GOMAXPROCS(-1) / 4
+	if np < 1 {
+		np = 1
+	}
 
 	visited := NewNodeSet(g.Order())
 
@@ -52,7 +54,7 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
 			workblocks = append(workblocks, currentLevel[i:end])
 		}
 
-		ch := make(chan []uint32, len(workblocks))
+		ch := make(chan []graph.Node, len(workblocks))
 		wg.Add(len(workblocks))
 		for _, block := range workblocks {
 			go func(block []graph.Node) {
@@ -69,7 +71,7 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
 			nextLevel = append(nextLevel, ns...)
 		}
 
-		zuint32.SortBYOB(nextLevel, currentLevel[:cap(currentLevel)])
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
 
 		for _, neighbor := range nextLevel {
 			level[neighbor] = levelNumber
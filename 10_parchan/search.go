@@ -3,8 +3,8 @@ package search
 import (
 	"runtime"
 
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/egonelbre/async"
 )
 
 func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
@@ -12,7 +12,7 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 		panic("invalid level length")
 	}
 
-	visited := NewNodeSet(g.Order())
+	visited := NewLocalNodeSet(g.Order(), procs)
 
 	currentLevel := make(chan graph.Node, g.Order())
 	nextLevel := make(chan graph.Node, g.Order())
@@ -23,7 +23,7 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 
 	levelNumber := 2
 	for len(currentLevel) > 0 {
-		async.Run(procs, func(gid int) {
+		affinity.Run(procs, func(gid int) {
 			runtime.LockOSThread()
 			for {
 				select {
@@ -2,11 +2,9 @@ package search
 
 import (
 	"runtime"
-	"sync/atomic"
 
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/egonelbre/async"
-	"github.com/shawnsmithdev/zermelo/zuint32"
 )
 
 const (
@@ -17,25 +15,25 @@ const (
 
 type Frontier struct {
 	Nodes []graph.Node
-	Head  uint32
+	Head  graph.Index
 }
 
-func (front *Frontier) NextRead() (low, high uint32) {
-	high = atomic.AddUint32(&front.Head, ReadBlockSize)
+func (front *Frontier) NextRead() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, ReadBlockSize)
 	low = high - ReadBlockSize
-	if high > uint32(len(front.Nodes)) {
-		high = uint32(len(front.Nodes))
+	if high > graph.Index(len(front.Nodes)) {
+		high = graph.Index(len(front.Nodes))
 	}
 	return
 }
 
-func (front *Frontier) NextWrite() (low, high uint32) {
-	high = atomic.AddUint32(&front.Head, WriteBlockSize)
+func (front *Frontier) NextWrite() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, WriteBlockSize)
 	low = high - WriteBlockSize
 	return
 }
 
-func (front *Frontier) Write(low, high *uint32, v graph.Node) {
+func (front *Frontier) Write(low, high *graph.Index, v graph.Node) {
 	if *low >= *high {
 		*low, *high = front.NextWrite()
 	}
@@ -44,7 +42,7 @@ func (front *Frontier) Write(low, high *uint32, v graph.Node) {
 }
 
 func process(g *graph.Graph, currentLevel, nextLevel *Frontier, visited NodeSet) {
-	writeLow, writeHigh := uint32(0), uint32(0)
+	writeLow, writeHigh := graph.Index(0), graph.Index(0)
 	for {
 		readLow, readHigh := currentLevel.NextRead()
 		if readLow >= readHigh {
@@ -93,12 +91,12 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 		panic("invalid level length")
 	}
 
-	visited := NewNodeSet(g.Order())
+	visited := NewLocalNodeSet(g.Order(), procs)
 
 	maxSize := g.Order() + WriteBlockSize*procs
 
-	currentLevel := &Frontier{make([]graph.Node, 0, maxSize), 0}
-	nextLevel := &Frontier{make([]graph.Node, maxSize, maxSize), 0}
+	currentLevel := &Frontier{affinity.Nodes(procs, maxSize)[:0], 0}
+	nextLevel := &Frontier{affinity.Nodes(procs, maxSize), 0}
 
 	level[source] = 1
 	visited.TryAdd(source)
@@ -107,14 +105,14 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 	levelNumber := 2
 
 	for len(currentLevel.Nodes) > 0 {
-		async.Run(procs, func(i int) {
+		affinity.Run(procs, func(i int) {
 			runtime.LockOSThread()
 			process(g, currentLevel, nextLevel, visited)
 		})
 
-		async.BlockIter(int(nextLevel.Head), procs, func(low, high int) {
+		affinity.BlockIter(int(nextLevel.Head), procs, func(low, high int) {
 			runtime.LockOSThread()
-			zuint32.SortBYOB(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
+			graph.SortNodes(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
 		})
 
 		for _, neighbor := range nextLevel.Nodes[:nextLevel.Head] {
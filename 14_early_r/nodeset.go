@@ -3,6 +3,7 @@ package search
 import (
 	"sync/atomic"
 
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
 	"github.com/egonelbre/a-tale-of-bfs/graph"
 )
 
@@ -18,8 +19,13 @@ func NewNodeSet(size int) NodeSet {
 	return NodeSet(make([]uint32, (size+31)/32))
 }
 
-func (set NodeSet) Offset(node graph.Node) (bucket, bit uint32) {
-	bucket = uint32(node >> bucket_bits)
+// NewLocalNodeSet splits the buckets between procs workers for first-touch.
+func NewLocalNodeSet(size, procs int) NodeSet {
+	return NodeSet(affinity.Uint32s(procs, (size+31)/32))
+}
+
+func (set NodeSet) Offset(node graph.Node) (bucket graph.Node, bit uint32) {
+	bucket = node >> bucket_bits
 	bit = uint32(1 << (node & bucket_mask))
 	return bucket, bit
 }
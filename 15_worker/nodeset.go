@@ -3,7 +3,9 @@ package search
 import (
 	"sync/atomic"
 
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
 	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
 )
 
 const (
@@ -18,8 +20,13 @@ func NewNodeSet(size int) NodeSet {
 	return NodeSet(make([]uint32, (size+31)/32))
 }
 
-func (set NodeSet) Offset(node graph.Node) (bucket, bit uint32) {
-	bucket = uint32(node >> bucket_bits)
+// NewLocalNodeSet splits the buckets between procs workers for first-touch.
+func NewLocalNodeSet(size, procs int) NodeSet {
+	return NodeSet(affinity.Uint32s(procs, (size+31)/32))
+}
+
+func (set NodeSet) Offset(node graph.Node) (bucket graph.Node, bit uint32) {
+	bucket = node >> bucket_bits
 	bit = uint32(1 << (node & bucket_mask))
 	return bucket, bit
 }
@@ -61,6 +68,9 @@ retry:
 	if atomic.CompareAndSwapUint32(addr, old, old|bit) {
 		return true
 	}
+	if tracing.Enabled {
+		tracing.CASFailed()
+	}
 	goto retry
 }
 
@@ -74,6 +84,9 @@ retry:
 	if atomic.CompareAndSwapUint32(addr, old, old|bit) {
 		return true
 	}
+	if tracing.Enabled {
+		tracing.CASFailed()
+	}
 	old = atomic.LoadUint32(addr)
 	if old&bit != 0 {
 		return false
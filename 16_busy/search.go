@@ -4,9 +4,9 @@ import (
 	"runtime"
 	"sync/atomic"
 
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
 	"github.com/egonelbre/a-tale-of-bfs/graph"
-	"github.com/egonelbre/async"
-	"github.com/shawnsmithdev/zermelo/zuint32"
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
 )
 
 const (
@@ -17,25 +17,25 @@ const (
 
 type Frontier struct {
 	Nodes []graph.Node
-	Head  uint32
+	Head  graph.Index
 }
 
-func (front *Frontier) NextRead() (low, high uint32) {
-	high = atomic.AddUint32(&front.Head, ReadBlockSize)
+func (front *Frontier) NextRead() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, ReadBlockSize)
 	low = high - ReadBlockSize
-	if high > uint32(len(front.Nodes)) {
-		high = uint32(len(front.Nodes))
+	if high > graph.Index(len(front.Nodes)) {
+		high = graph.Index(len(front.Nodes))
 	}
 	return
 }
 
-func (front *Frontier) NextWrite() (low, high uint32) {
-	high = atomic.AddUint32(&front.Head, WriteBlockSize)
+func (front *Frontier) NextWrite() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, WriteBlockSize)
 	low = high - WriteBlockSize
 	return
 }
 
-func (front *Frontier) Write(low, high *uint32, v graph.Node) {
+func (front *Frontier) Write(low, high *graph.Index, v graph.Node) {
 	if *low >= *high {
 		*low, *high = front.NextWrite()
 	}
@@ -43,8 +43,9 @@ func (front *Frontier) Write(low, high *uint32, v graph.Node) {
 	*low += 1
 }
 
-func process(g *graph.Graph, currentLevel, nextLevel *Frontier, visited NodeSet) {
-	writeLow, writeHigh := uint32(0), uint32(0)
+func process(g *graph.Graph, currentLevel, nextLevel *Frontier, visited NodeSet, trace *tracing.Level, gid int) {
+	writeLow, writeHigh := graph.Index(0), graph.Index(0)
+	edges, discovered := int64(0), int64(0)
 	for {
 		readLow, readHigh := currentLevel.NextRead()
 		if readLow >= readHigh {
@@ -58,27 +59,45 @@ func process(g *graph.Graph, currentLevel, nextLevel *Frontier, visited NodeSet)
 
 			neighbors := g.Neighbors(node)
 			i := 0
+			if tracing.Enabled {
+				edges += int64(len(neighbors))
+			}
 
 			for ; i < len(neighbors)-3; i += 4 {
 				n1, n2, n3, n4 := neighbors[i], neighbors[i+1], neighbors[i+2], neighbors[i+3]
 				x1, x2, x3, x4 := visited.GetBuckets4(n1, n2, n3, n4)
 				if visited.TryAddFrom(x1, n1) {
 					nextLevel.Write(&writeLow, &writeHigh, n1)
+					if tracing.Enabled {
+						discovered++
+					}
 				}
 				if visited.TryAddFrom(x2, n2) {
 					nextLevel.Write(&writeLow, &writeHigh, n2)
+					if tracing.Enabled {
+						discovered++
+					}
 				}
 				if visited.TryAddFrom(x3, n3) {
 					nextLevel.Write(&writeLow, &writeHigh, n3)
+					if tracing.Enabled {
+						discovered++
+					}
 				}
 				if visited.TryAddFrom(x4, n4) {
 					nextLevel.Write(&writeLow, &writeHigh, n4)
+					if tracing.Enabled {
+						discovered++
+					}
 				}
 			}
 
 			for _, n := range neighbors[i:] {
 				if visited.TryAdd(n) {
 					nextLevel.Write(&writeLow, &writeHigh, n)
+					if tracing.Enabled {
+						discovered++
+					}
 				}
 			}
 		}
@@ -87,6 +106,10 @@ func process(g *graph.Graph, currentLevel, nextLevel *Frontier, visited NodeSet)
 	for i := writeLow; i < writeHigh; i += 1 {
 		nextLevel.Nodes[i] = SentinelNode
 	}
+
+	if tracing.Enabled {
+		trace.Count(gid, edges, discovered)
+	}
 }
 
 func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
@@ -94,12 +117,12 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 		panic("invalid level length")
 	}
 
-	visited := NewNodeSet(g.Order())
+	visited := NewLocalNodeSet(g.Order(), procs)
 
 	maxSize := g.Order() + WriteBlockSize*procs
 
-	currentLevel := &Frontier{make([]graph.Node, 0, maxSize), 0}
-	nextLevel := &Frontier{make([]graph.Node, maxSize, maxSize), 0}
+	currentLevel := &Frontier{affinity.Nodes(procs, maxSize)[:0], 0}
+	nextLevel := &Frontier{affinity.Nodes(procs, maxSize), 0}
 
 	level[source] = 1
 	visited.TryAdd(source)
@@ -113,13 +136,19 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 
 	allDone := uint32(0)
 
+	// trace is only replaced by the last worker between the barriers
+	var trace *tracing.Level
+
 	worker := func(gid int) {
 		runtime.LockOSThread()
 
 		for atomic.LoadUint32(&allDone) == 0 {
+			trace := trace
 			{
 				// process the current level in parallel
-				process(g, currentLevel, nextLevel, visited)
+				span := trace.Begin(gid, tracing.Expand)
+				process(g, currentLevel, nextLevel, visited, trace, gid)
+				span.End()
 			}
 
 			// use a counter to see how many are still processing
@@ -137,21 +166,16 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 				waitForLast1.Done()
 			} else {
 				// wait for the last one finishing processing to setup for the next phase
+				span := trace.Begin(gid, tracing.Wait)
 				waitForLast1.Wait()
+				span.End()
 			}
 
 			{
-				// sort a part of the nextLevel in equal chunks
-				blockSize := (len(nextLevel.Nodes) + procs - 1) / procs
-
-				low := blockSize * gid
-				high := low + blockSize
-				if high > len(nextLevel.Nodes) {
-					high = len(nextLevel.Nodes)
-				}
-
-				if low < len(nextLevel.Nodes) {
-					zuint32.SortBYOB(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
+				// sort the part of the nextLevel owned by this worker
+				span := trace.Begin(gid, tracing.Sort)
+				affinity.Blocks(len(nextLevel.Nodes), procs, gid, func(low, high int) {
+					graph.SortNodes(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
 					// update the vertLevels
 					//    sentinels are sorted to the end of the array,
 					//    so we can break when we find the first one
@@ -161,7 +185,8 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 						}
 						level[v] = levelNumber
 					}
-				}
+				})
+				span.End()
 			}
 
 			// similarly to before, the last one finishing, does the setup for next phase
@@ -176,6 +201,8 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 					// if we are done, set the allDone flag
 					if len(currentLevel.Nodes) == 0 {
 						atomic.StoreUint32(&allDone, 1)
+					} else if tracing.Enabled {
+						trace = tracing.BeginLevel(levelNumber-1, len(currentLevel.Nodes), procs)
 					}
 				}
 
@@ -186,26 +213,36 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 				waitForLast2.Done()
 			} else {
 				// wait for the last one to finish
+				span := trace.Begin(gid, tracing.Wait)
 				waitForLast2.Wait()
+				span.End()
 			}
 		}
 	}
 
 	for len(currentLevel.Nodes) > 0 {
-		async.Run(procs, func(i int) {
+		if tracing.Enabled {
+			trace = tracing.BeginLevel(levelNumber-1, len(currentLevel.Nodes), procs)
+		}
+
+		affinity.Run(procs, func(i int) {
 			runtime.LockOSThread()
-			process(g, currentLevel, nextLevel, visited)
+			span := trace.Begin(i, tracing.Expand)
+			process(g, currentLevel, nextLevel, visited, trace, i)
+			span.End()
 		})
 
-		async.BlockIter(int(nextLevel.Head), procs, func(low, high int) {
+		affinity.BlockIter(int(nextLevel.Head), procs, func(low, high int) {
 			runtime.LockOSThread()
-			zuint32.SortBYOB(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
+			span := trace.Begin(tracing.AnyWorker, tracing.Sort)
+			graph.SortNodes(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
 			for _, neighbor := range nextLevel.Nodes[low:high] {
 				if neighbor == SentinelNode {
 					break
 				}
 				level[neighbor] = levelNumber
 			}
+			span.End()
 		})
 
 		levelNumber++
@@ -218,10 +255,12 @@ func BreadthFirst(g *graph.Graph, source graph.Node, level []int, procs int) {
 		nextLevel.Head = 0
 	}
 
-	for gid := 1; gid < procs; gid++ {
-		go worker(gid)
+	if tracing.Enabled {
+		trace = tracing.BeginLevel(levelNumber-1, len(currentLevel.Nodes), procs)
 	}
-	worker(0)
+
+	// join the workers, so that they don't outlive the search
+	affinity.Run(procs, worker)
 }
 
 type BusyGroup struct{ sema int32 }
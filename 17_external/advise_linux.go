@@ -0,0 +1,17 @@
+package search
+
+import (
+	"os"
+	"syscall"
+)
+
+var pageSize = uintptr(os.Getpagesize())
+
+func willneed(addr, size uintptr) {
+	if size == 0 {
+		return
+	}
+	start := addr &^ (pageSize - 1)
+	end := (addr + size + pageSize - 1) &^ (pageSize - 1)
+	syscall.Syscall(syscall.SYS_MADVISE, start, end-start, syscall.MADV_WILLNEED)
+}
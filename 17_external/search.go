@@ -0,0 +1,93 @@
+package search
+
+import (
+	"unsafe"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+const (
+	// AdviseWindow is the number of frontier nodes whose adjacency is
+	// requested ahead of processing.
+	AdviseWindow = 1024
+	// AdviseGap is the distance in bytes under which neighboring
+	// adjacency ranges are merged into a single hint.
+	AdviseGap = 1 << 16
+)
+
+// BreadthFirst is meant for graphs loaded with graph.MapDAT, where the
+// adjacency list does not fit into memory. Only the visited set and the
+// frontiers are kept in memory, the adjacency is read in sorted frontier
+// order and the kernel is asked to read ahead the next window.
+func BreadthFirst(g *graph.Graph, source graph.Node, level []int) {
+	if len(level) != g.Order() {
+		panic("invalid level length")
+	}
+
+	visited := NewNodeSet(g.Order())
+
+	currentLevel := make([]graph.Node, 0, g.Order())
+	nextLevel := make([]graph.Node, 0, g.Order())
+
+	level[source] = 1
+	visited.Add(source)
+	currentLevel = append(currentLevel, source)
+
+	levelNumber := 2
+
+	for len(currentLevel) > 0 {
+		advise(g, window(currentLevel, 0))
+		for low := 0; low < len(currentLevel); low += AdviseWindow {
+			advise(g, window(currentLevel, low+AdviseWindow))
+
+			for _, node := range window(currentLevel, low) {
+				for _, neighbor := range g.Neighbors(node) {
+					if !visited.Contains(neighbor) {
+						visited.Add(neighbor)
+						nextLevel = append(nextLevel, neighbor)
+					}
+				}
+			}
+		}
+
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
+
+		for _, neighbor := range nextLevel {
+			level[neighbor] = levelNumber
+		}
+
+		levelNumber++
+		currentLevel = currentLevel[:0:cap(currentLevel)]
+		currentLevel, nextLevel = nextLevel, currentLevel
+	}
+}
+
+func window(nodes []graph.Node, low int) []graph.Node {
+	if low >= len(nodes) {
+		return nil
+	}
+	high := low + AdviseWindow
+	if high > len(nodes) {
+		high = len(nodes)
+	}
+	return nodes[low:high]
+}
+
+// advise requests the adjacency of sorted nodes to be read in.
+func advise(g *graph.Graph, nodes []graph.Node) {
+	if len(nodes) == 0 || len(g.List) == 0 {
+		return
+	}
+
+	base := uintptr(unsafe.Pointer(&g.List[0]))
+	start, end := g.Span[nodes[0]], g.Span[nodes[0]+1]
+	for _, node := range nodes[1:] {
+		low, high := g.Span[node], g.Span[node+1]
+		if (low-end)*graph.NodeSize > AdviseGap {
+			willneed(base+uintptr(start*graph.NodeSize), uintptr((end-start)*graph.NodeSize))
+			start = low
+		}
+		end = high
+	}
+	willneed(base+uintptr(start*graph.NodeSize), uintptr((end-start)*graph.NodeSize))
+}
@@ -0,0 +1,397 @@
+// Package affinity pins worker threads to CPUs and allocates memory with
+// first-touch by the workers, so that on NUMA machines the pages are spread
+// over the nodes of the workers.
+//
+// The policy is process wide and "none" by default, in which case Run and
+// the allocation helpers leave placement to the OS.
+package affinity
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/async"
+)
+
+// CPU is a logical CPU and its place in the topology.
+type CPU struct {
+	ID      int
+	Node    int
+	Package int
+	Core    int
+}
+
+// Policy assigns workers to CPUs, worker i runs on CPUs[i % len(CPUs)].
+type Policy struct {
+	// Name is "none", "compact", "scatter" or "list".
+	Name string
+	CPUs []int
+	// Nodes is the number of NUMA nodes the CPUs span.
+	Nodes int
+}
+
+var current = Policy{Name: "none"}
+
+// Set makes p the policy used by Run and the allocation helpers,
+// the workers of the previous policy are stopped.
+func Set(p Policy) {
+	pool.Lock()
+	defer pool.Unlock()
+	stop()
+	current = p
+}
+
+// Current returns the active policy.
+func Current() Policy { return current }
+
+// Enabled reports whether workers are pinned.
+func (p Policy) Enabled() bool { return len(p.CPUs) > 0 }
+
+// CPU returns the CPU of worker.
+func (p Policy) CPU(worker int) int { return p.CPUs[worker%len(p.CPUs)] }
+
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return p.Name
+	}
+	nodes := "nodes"
+	if p.Nodes == 1 {
+		nodes = "node"
+	}
+	return fmt.Sprintf("%v %v (%d %v)", p.Name, FormatCPUs(p.CPUs), p.Nodes, nodes)
+}
+
+// Parse creates a policy for the CPUs in topology:
+//
+//	none     placement is left to the OS
+//	compact  fill the cores of a node before moving to the next one
+//	scatter  alternate between nodes, using every core before hyper-threads
+//	0-3,8    an explicit list, workers are assigned in the listed order
+func Parse(spec string, topology []CPU) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "", "none":
+		return Policy{Name: "none"}, nil
+	}
+	if len(topology) == 0 {
+		return Policy{}, fmt.Errorf("affinity %q: no cpus available", spec)
+	}
+
+	var p Policy
+	switch spec {
+	case "compact":
+		p = Policy{Name: spec, CPUs: Compact(topology)}
+	case "scatter":
+		p = Policy{Name: spec, CPUs: Scatter(topology)}
+	default:
+		list, err := ParseCPUs(spec)
+		if err != nil {
+			return Policy{}, fmt.Errorf("affinity: expected none, compact, scatter or a cpu list: %w", err)
+		}
+		p = Policy{Name: "list", CPUs: list}
+	}
+
+	nodes := map[int]bool{}
+	for _, id := range p.CPUs {
+		cpu, ok := find(topology, id)
+		if !ok {
+			return Policy{}, fmt.Errorf("affinity: cpu %d is not available", id)
+		}
+		nodes[cpu.Node] = true
+	}
+	p.Nodes = len(nodes)
+	return p, nil
+}
+
+func find(topology []CPU, id int) (CPU, bool) {
+	for _, cpu := range topology {
+		if cpu.ID == id {
+			return cpu, true
+		}
+	}
+	return CPU{}, false
+}
+
+// Compact orders CPUs by node, core and hyper-thread,
+// so that consecutive workers share caches.
+func Compact(topology []CPU) []int {
+	cpus := append([]CPU(nil), topology...)
+	sort.Slice(cpus, func(i, k int) bool {
+		a, b := cpus[i], cpus[k]
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Core != b.Core {
+			return a.Core < b.Core
+		}
+		return a.ID < b.ID
+	})
+
+	ids := make([]int, len(cpus))
+	for i, cpu := range cpus {
+		ids[i] = cpu.ID
+	}
+	return ids
+}
+
+// Scatter orders CPUs round-robin over nodes, within a node the first
+// hyper-thread of every core is used before the second ones.
+func Scatter(topology []CPU) []int {
+	type core struct{ pkg, core int }
+	thread := map[int]int{}
+	seen := map[core]int{}
+	for _, id := range Compact(topology) {
+		cpu, _ := find(topology, id)
+		key := core{cpu.Package, cpu.Core}
+		thread[id] = seen[key]
+		seen[key]++
+	}
+
+	perNode := map[int][]CPU{}
+	var nodes []int
+	for _, cpu := range topology {
+		if _, ok := perNode[cpu.Node]; !ok {
+			nodes = append(nodes, cpu.Node)
+		}
+		perNode[cpu.Node] = append(perNode[cpu.Node], cpu)
+	}
+	sort.Ints(nodes)
+	for _, node := range nodes {
+		cpus := perNode[node]
+		sort.Slice(cpus, func(i, k int) bool {
+			a, b := cpus[i], cpus[k]
+			if thread[a.ID] != thread[b.ID] {
+				return thread[a.ID] < thread[b.ID]
+			}
+			if a.Package != b.Package {
+				return a.Package < b.Package
+			}
+			if a.Core != b.Core {
+				return a.Core < b.Core
+			}
+			return a.ID < b.ID
+		})
+	}
+
+	var ids []int
+	for i := 0; len(ids) < len(topology); i++ {
+		for _, node := range nodes {
+			if cpus := perNode[node]; i < len(cpus) {
+				ids = append(ids, cpus[i].ID)
+			}
+		}
+	}
+	return ids
+}
+
+// ParseCPUs parses a CPU list such as "0-3,8".
+func ParseCPUs(list string) ([]int, error) {
+	var cpus []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		lo, hi := field, field
+		if p := strings.Index(field, "-"); p >= 0 {
+			lo, hi = field[:p], field[p+1:]
+		}
+		from, err1 := strconv.Atoi(lo)
+		to, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || from < 0 || to < from {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("empty cpu list %q", list)
+	}
+	return cpus, nil
+}
+
+// FormatCPUs is the inverse of ParseCPUs, ascending runs are written as ranges.
+func FormatCPUs(cpus []int) string {
+	var fields []string
+	for i := 0; i < len(cpus); {
+		k := i + 1
+		for k < len(cpus) && cpus[k] == cpus[k-1]+1 {
+			k++
+		}
+		if k-i > 1 {
+			fields = append(fields, fmt.Sprintf("%d-%d", cpus[i], cpus[k-1]))
+		} else {
+			fields = append(fields, strconv.Itoa(cpus[i]))
+		}
+		i = k
+	}
+	return strings.Join(fields, ",")
+}
+
+// pool holds long-lived workers, worker i is locked to its thread and
+// pinned to the CPU of worker i once, when it starts.
+var pool struct {
+	sync.Mutex
+	work []chan task
+}
+
+type task struct {
+	fn   func(worker int)
+	done *sync.WaitGroup
+}
+
+// Start starts and pins the first workers, so that pinning
+// isn't part of the first measured Run.
+func Start(workers int) error {
+	pool.Lock()
+	defer pool.Unlock()
+	if !current.Enabled() {
+		return nil
+	}
+	return start(workers)
+}
+
+func start(workers int) error {
+	var first error
+	for len(pool.work) < workers {
+		worker := len(pool.work)
+		work, started := make(chan task), make(chan error)
+		go serve(worker, current.CPU(worker), work, started)
+		if err := <-started; err != nil && first == nil {
+			first = fmt.Errorf("affinity: pinning worker %d to cpu %d: %w", worker, current.CPU(worker), err)
+		}
+		pool.work = append(pool.work, work)
+	}
+	return first
+}
+
+func serve(worker, cpu int, work chan task, started chan error) {
+	// the thread exits with the goroutine, so the affinity isn't restored
+	runtime.LockOSThread()
+	started <- pin(cpu)
+	for t := range work {
+		t.fn(worker)
+		t.done.Done()
+	}
+}
+
+// stop stops the workers, so that they are pinned again for the next policy.
+func stop() {
+	for _, work := range pool.work {
+		close(work)
+	}
+	pool.work = nil
+}
+
+// Run is async.Run, with fn(i) called by worker i of the pool. Runs
+// don't overlap, since the workers of a search may wait for each other.
+func Run(procs int, fn func(worker int)) {
+	if !current.Enabled() {
+		async.Run(procs, fn)
+		return
+	}
+
+	pool.Lock()
+	defer pool.Unlock()
+	start(procs)
+
+	var done sync.WaitGroup
+	done.Add(procs)
+	for worker := 0; worker < procs; worker++ {
+		pool.work[worker] <- task{fn, &done}
+	}
+	done.Wait()
+}
+
+// Blocks calls fn with the blocks of count nodes owned by worker.
+// Without a policy the nodes are split into procs equal blocks. With a
+// policy worker owns every procs-th page of nodes, which are the pages it
+// touched when Nodes allocated them.
+func Blocks(count, procs, worker int, fn func(low, high int)) {
+	if !current.Enabled() {
+		blockSize := (count + procs - 1) / procs
+		low := blockSize * worker
+		high := low + blockSize
+		if high > count {
+			high = count
+		}
+		if low < high {
+			fn(low, high)
+		}
+		return
+	}
+
+	page := pageLen(int(unsafe.Sizeof(graph.Node(0))))
+	for low := page * worker; low < count; low += page * procs {
+		high := low + page
+		if high > count {
+			high = count
+		}
+		fn(low, high)
+	}
+}
+
+// BlockIter is async.BlockIter, with a policy the blocks
+// are the pages owned by each worker, see Blocks.
+func BlockIter(count, procs int, fn func(low, high int)) {
+	if !current.Enabled() {
+		async.BlockIter(count, procs, fn)
+		return
+	}
+	Run(procs, func(worker int) {
+		Blocks(count, procs, worker, fn)
+	})
+}
+
+// Nodes allocates n nodes, see touch.
+func Nodes(procs, n int) []graph.Node {
+	nodes := make([]graph.Node, n)
+	touch(procs, n, int(unsafe.Sizeof(graph.Node(0))), func(i int) { nodes[i] = 0 })
+	return nodes
+}
+
+// Uint32s allocates n uint32s, see touch.
+func Uint32s(procs, n int) []uint32 {
+	xs := make([]uint32, n)
+	touch(procs, n, 4, func(i int) { xs[i] = 0 })
+	return xs
+}
+
+// pageLen is the number of elements of size bytes in a page.
+func pageLen(size int) int {
+	if n := os.Getpagesize() / size; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// touch writes to the pages of n elements round-robin from the workers,
+// worker k touching pages k, k+procs, ... For frontiers these are the pages
+// the worker sorts, see Blocks, the visited set is shared by every worker
+// and only interleaved. Large allocations start at a page boundary. Only
+// pages that haven't been touched yet are placed, memory reused by the Go
+// heap stays where it is.
+func touch(procs, n, size int, write func(i int)) {
+	if !current.Enabled() || procs < 1 || n == 0 {
+		return
+	}
+
+	page := pageLen(size)
+	Run(procs, func(worker int) {
+		for i := page * worker; i < n; i += page * procs {
+			write(i)
+		}
+	})
+}
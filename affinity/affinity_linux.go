@@ -0,0 +1,96 @@
+package affinity
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// pin moves the calling thread to cpu,
+// the goroutine must be locked to its thread.
+func pin(cpu int) error {
+	var set unix.CPUSet
+	set.Set(cpu)
+	return unix.SchedSetaffinity(0, &set)
+}
+
+// SetProcess pins every thread of the process to cpus,
+// threads started afterwards inherit the affinity.
+func SetProcess(cpus []int) error {
+	var set unix.CPUSet
+	set.Zero()
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+
+	tasks, err := ioutil.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := unix.SchedSetaffinity(tid, &set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Topology describes the CPUs in allowed, or the CPUs the process may run on
+// when allowed is empty. Without NUMA information the package is used as the node.
+func Topology(allowed []int) ([]CPU, error) {
+	if len(allowed) == 0 {
+		var set unix.CPUSet
+		if err := unix.SchedGetaffinity(0, &set); err != nil {
+			return nil, err
+		}
+		for cpu := 0; cpu < len(set)*64; cpu++ {
+			if set.IsSet(cpu) {
+				allowed = append(allowed, cpu)
+			}
+		}
+	}
+
+	nodes := map[int]int{}
+	dirs, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*")
+	for _, dir := range dirs {
+		node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		cpus, err := ParseCPUs(readSys(filepath.Join(dir, "cpulist")))
+		if err != nil {
+			continue
+		}
+		for _, cpu := range cpus {
+			nodes[cpu] = node
+		}
+	}
+
+	var topology []CPU
+	for _, id := range allowed {
+		dir := "/sys/devices/system/cpu/cpu" + strconv.Itoa(id) + "/topology/"
+		pkg, _ := strconv.Atoi(readSys(dir + "physical_package_id"))
+		core, err := strconv.Atoi(readSys(dir + "core_id"))
+		if err != nil {
+			core = id
+		}
+		node, ok := nodes[id]
+		if !ok {
+			node = pkg
+		}
+		topology = append(topology, CPU{ID: id, Node: node, Package: pkg, Core: core})
+	}
+	return topology, nil
+}
+
+func readSys(path string) string {
+	data, _ := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,58 @@
+package affinity
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestPinned(t *testing.T) {
+	topology, err := Topology(nil)
+	if err != nil {
+		t.Skip(err)
+	}
+	p, err := Parse("compact", topology)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Set(p)
+	defer Set(Policy{Name: "none"})
+
+	const procs, n = 3, 100000
+	if err := Start(procs); err != nil {
+		t.Fatal(err)
+	}
+
+	masks := make([]unix.CPUSet, procs)
+	errs := make([]error, procs)
+	Run(procs, func(worker int) {
+		errs[worker] = unix.SchedGetaffinity(0, &masks[worker])
+	})
+	for worker, mask := range masks {
+		if errs[worker] != nil {
+			t.Fatal(errs[worker])
+		}
+		var expected unix.CPUSet
+		expected.Set(p.CPU(worker))
+		if mask != expected {
+			t.Errorf("worker %d: got mask %v, expected cpu %d", worker, mask, p.CPU(worker))
+		}
+	}
+
+	xs := Uint32s(procs, n)
+	if len(xs) != n {
+		t.Fatalf("got %d uint32s, expected %d", len(xs), n)
+	}
+
+	counts := make([]int, n)
+	BlockIter(n, procs, func(low, high int) {
+		for i := low; i < high; i++ {
+			counts[i]++
+		}
+	})
+	for i, count := range counts {
+		if count != 1 {
+			t.Fatalf("element %d iterated %d times", i, count)
+		}
+	}
+}
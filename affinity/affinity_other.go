@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package affinity
+
+import "errors"
+
+func pin(cpu int) error {
+	return errors.New("cpu affinity is only supported on linux")
+}
+
+func SetProcess(cpus []int) error {
+	return errors.New("cpu affinity is only supported on linux")
+}
+
+func Topology(allowed []int) ([]CPU, error) {
+	return nil, errors.New("cpu affinity is only supported on linux")
+}
@@ -0,0 +1,96 @@
+package affinity
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// twoSockets has 2 nodes with 2 cores and 2 hyper-threads each,
+// numbered like Linux does on dual-socket Xeons.
+var twoSockets = []CPU{
+	{ID: 0, Node: 0, Package: 0, Core: 0},
+	{ID: 1, Node: 0, Package: 0, Core: 1},
+	{ID: 2, Node: 1, Package: 1, Core: 0},
+	{ID: 3, Node: 1, Package: 1, Core: 1},
+	{ID: 4, Node: 0, Package: 0, Core: 0},
+	{ID: 5, Node: 0, Package: 0, Core: 1},
+	{ID: 6, Node: 1, Package: 1, Core: 0},
+	{ID: 7, Node: 1, Package: 1, Core: 1},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Policy
+	}{
+		{"none", Policy{Name: "none"}},
+		{"compact", Policy{Name: "compact", CPUs: []int{0, 4, 1, 5, 2, 6, 3, 7}, Nodes: 2}},
+		{"scatter", Policy{Name: "scatter", CPUs: []int{0, 2, 1, 3, 4, 6, 5, 7}, Nodes: 2}},
+		{"4-5,0", Policy{Name: "list", CPUs: []int{4, 5, 0}, Nodes: 1}},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.spec, twoSockets)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %+v, expected %+v", test.spec, got, test.want)
+		}
+	}
+
+	for _, spec := range []string{"8", "spread", "3-1"} {
+		if _, err := Parse(spec, twoSockets); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestFormatCPUs(t *testing.T) {
+	for _, list := range []string{"0", "0-3,8", "0,2,1,3", "4-5,0-1"} {
+		cpus, err := ParseCPUs(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatCPUs(cpus); got != list {
+			t.Errorf("got %q, expected %q", got, list)
+		}
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	defer Set(Policy{Name: "none"})
+
+	page := pageLen(int(unsafe.Sizeof(graph.Node(0))))
+	for _, p := range []Policy{{Name: "none"}, {Name: "list", CPUs: []int{0}, Nodes: 1}} {
+		Set(p)
+		for _, count := range []int{0, 5, page, 10*page + 3} {
+			const procs = 3
+			owner := make([]int, count)
+			for i := range owner {
+				owner[i] = -1
+			}
+			for worker := 0; worker < procs; worker++ {
+				Blocks(count, procs, worker, func(low, high int) {
+					for i := low; i < high; i++ {
+						if owner[i] >= 0 {
+							t.Fatalf("%v: node %d owned by %d and %d", p.Name, i, owner[i], worker)
+						}
+						owner[i] = worker
+					}
+				})
+			}
+			for i, worker := range owner {
+				if worker < 0 {
+					t.Fatalf("%v: node %d of %d not owned", p.Name, i, count)
+				}
+				if p.Enabled() && worker != i/page%procs {
+					t.Fatalf("%v: node %d owned by %d, expected %d", p.Name, i, worker, i/page%procs)
+				}
+			}
+		}
+	}
+}
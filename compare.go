@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+// Compare compares result files against the first one,
+// it returns 1 when there are regressions or missing approaches and 2 on errors.
+func Compare(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	threshold := flags.Float64("threshold", 0.05, "slowdown ratio considered a regression")
+	alpha := flags.Float64("alpha", 0.05, "significance level")
+	confidence := flags.Float64("confidence", 0.95, "confidence interval size")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: a-tale-of-bfs compare [flags] old.txt new.txt [new2.txt ...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return 2
+	}
+
+	results := make([]*measure.Result, flags.NArg())
+	for i, name := range flags.Args() {
+		var err error
+		results[i], err = measure.ParseFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, name, err)
+			return 2
+		}
+	}
+
+	regressions, missing := 0, 0
+	base := results[0]
+	for i, result := range results[1:] {
+		fmt.Printf("# %v vs %v\n", flags.Arg(0), flags.Arg(i+1))
+		fmt.Println("dataset\tapproach\told\tnew\tspeedup\tlow\thigh\tp\tverdict")
+		for _, old := range base.Measurements {
+			cur := result.Measurements.Entry(old.Entry)
+			if cur.Approach == "" {
+				fmt.Printf("%v\t%v\t%.2f\t\t\t\t\t\tMISSING\n", old.Dataset, old.Approach, old.Median)
+				missing++
+				continue
+			}
+
+			c := measure.Compare(old, cur, *confidence)
+			significant := c.P < *alpha || len(old.Timings) == 0 || len(cur.Timings) == 0
+
+			verdict := "~"
+			switch {
+			case significant && c.Speedup < 1-*threshold:
+				verdict = "REGRESSION"
+				regressions++
+			case significant && c.Speedup > 1+*threshold:
+				verdict = "faster"
+			}
+
+			fmt.Printf("%v\t%v\t%.2f\t%.2f\t%.3f\t%.3f\t%.3f\t%.4f\t%v\n",
+				c.Dataset, c.Approach, old.Median, cur.Median,
+				c.Speedup, c.Low, c.High, c.P, verdict)
+		}
+	}
+
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "%d approaches missing\n", missing)
+	}
+	if regressions > 0 {
+		fmt.Fprintf(os.Stderr, "%d regressions\n", regressions)
+	}
+	if regressions > 0 || missing > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+var (
+	manifest  = flag.String("manifest", "datasets.json", "dataset manifest, ignored when the default is missing")
+	checksums = flag.String("checksums", "", "comma separated sha256 of the datasets (set by -isolate and -ranks)")
+)
+
+// verifyFile and verifySource check approaches when the manifest has no verify dataset.
+const (
+	verifyFile   = "data/sg-10k-250k.txt"
+	verifySource = 2
+)
+
+type Dataset struct {
+	Name    string
+	Graph   *graph.Graph
+	Sources []graph.Node
+	Info    measure.Dataset
+	Entry   ManifestEntry
+}
+
+// Manifest describes datasets, so they can be referred to by name
+// and validated before benchmarking.
+type Manifest struct {
+	// Verify is the dataset used to check approaches before benchmarking.
+	Verify   string          `json:"verify,omitempty"`
+	Datasets []ManifestEntry `json:"datasets"`
+}
+
+// ManifestEntry describes a single dataset, zero values are not validated.
+type ManifestEntry struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	// Format is "txt" or "dat", defaults to the file extension.
+	Format   string `json:"format,omitempty"`
+	Nodes    int    `json:"nodes,omitempty"`
+	Edges    int    `json:"edges,omitempty"`
+	Checksum string `json:"sha256,omitempty"`
+	// Size and ModTime describe the file that had Checksum,
+	// the checksum is reused while they match.
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mtime,omitempty"`
+	// Sources are used when -source and -sources are not specified.
+	Sources []int `json:"sources,omitempty"`
+	// Levels is the number of nodes at each level searching from the first source.
+	Levels []int `json:"levels,omitempty"`
+}
+
+// LoadManifest reads filename, files are relative to the manifest.
+func LoadManifest(filename string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%v: %w", filename, err)
+	}
+
+	dir := filepath.Dir(filename)
+	for i := range m.Datasets {
+		entry := &m.Datasets[i]
+		if entry.Name == "" || entry.File == "" {
+			return nil, fmt.Errorf("%v: dataset %d needs a name and a file", filename, i)
+		}
+		if !filepath.IsAbs(entry.File) {
+			entry.File = filepath.Join(dir, entry.File)
+		}
+	}
+	return m, nil
+}
+
+// ReadManifest loads -manifest, a missing default manifest is not an error
+// and an empty -manifest disables it.
+func ReadManifest() (*Manifest, error) {
+	if *manifest == "" {
+		return &Manifest{}, nil
+	}
+
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "manifest" })
+
+	m, err := LoadManifest(*manifest)
+	if os.IsNotExist(err) && !explicit {
+		return &Manifest{}, nil
+	}
+	return m, err
+}
+
+// Lookup finds a dataset by name or file.
+func (m *Manifest) Lookup(name string) (ManifestEntry, bool) {
+	for _, entry := range m.Datasets {
+		if entry.Name == name || filepath.Clean(entry.File) == filepath.Clean(name) {
+			return entry, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// Entries resolves command line arguments to datasets, "all" is every dataset
+// in the manifest and arguments not in the manifest are treated as files.
+func (m *Manifest) Entries(args []string) []ManifestEntry {
+	var entries []ManifestEntry
+	for _, arg := range args {
+		if arg == "all" {
+			entries = append(entries, m.Datasets...)
+			continue
+		}
+		entry, ok := m.Lookup(arg)
+		if !ok {
+			entry = ManifestEntry{Name: removeExt(filepath.Base(arg)), File: arg}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// VerifyEntry is the dataset used to check approaches.
+func (m *Manifest) VerifyEntry() (ManifestEntry, graph.Node, error) {
+	entry := ManifestEntry{Name: removeExt(filepath.Base(verifyFile)), File: verifyFile}
+	if m.Verify != "" {
+		var ok bool
+		entry, ok = m.Lookup(m.Verify)
+		if !ok {
+			return entry, 0, fmt.Errorf("verify dataset %q not in manifest", m.Verify)
+		}
+	}
+
+	source := graph.Node(verifySource)
+	if len(entry.Sources) > 0 {
+		source = graph.Node(entry.Sources[0])
+	}
+	return entry, source, nil
+}
+
+// LoadGraph loads the graph in entry, close must be called when done.
+func (entry *ManifestEntry) LoadGraph() (g *graph.Graph, close func(), err error) {
+	format := entry.Format
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(entry.File), ".")
+	}
+
+	close = func() {}
+	switch format {
+	case "dat":
+		if *mmapped {
+			var m *graph.Mapped
+			m, err = graph.MapDAT(entry.File)
+			if m != nil {
+				g, close = &m.Graph, func() { m.Close() }
+			}
+		} else {
+			g, err = graph.LoadDAT(entry.File)
+		}
+	case "txt":
+		g, err = graph.LoadText(entry.File)
+	default:
+		err = fmt.Errorf("unknown file format: %v", entry.File)
+	}
+	return g, close, err
+}
+
+// FileChecksum returns the sha256 of the file, the checksum in the manifest
+// is reused while the size and modification time of the file match.
+func (entry *ManifestEntry) FileChecksum() (string, error) {
+	if entry.Checksum != "" && !entry.ModTime.IsZero() {
+		stat, err := os.Stat(entry.File)
+		if err != nil {
+			return "", err
+		}
+		if stat.Size() == entry.Size && stat.ModTime().Equal(entry.ModTime) {
+			return entry.Checksum, nil
+		}
+	}
+	return measure.Checksum(entry.File)
+}
+
+// LoadDataset loads the graph and picks the sources for benchmarking,
+// the file is only hashed when checksum is empty.
+func LoadDataset(entry ManifestEntry, checksum string) (Dataset, func(), error) {
+	g, close, err := entry.LoadGraph()
+	if err != nil {
+		return Dataset{}, close, err
+	}
+
+	if checksum == "" {
+		checksum, err = entry.FileChecksum()
+		if err != nil {
+			return Dataset{}, close, err
+		}
+	}
+
+	dataset := Dataset{
+		Name:  entry.Name,
+		Graph: g,
+		Entry: entry,
+	}
+	dataset.Info = measure.Dataset{
+		Name:     entry.Name,
+		File:     entry.File,
+		Checksum: checksum,
+		Nodes:    g.Order(),
+		Edges:    g.Size(),
+	}
+
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "source" })
+	switch {
+	case IsIsolated():
+		dataset.Sources, err = IsolatedSources()
+		if err != nil {
+			return Dataset{}, close, err
+		}
+	case *sources > 0:
+		dataset.Sources, err = PickSources(g, *sources, *seed)
+		if err != nil {
+			return Dataset{}, close, fmt.Errorf("%v: %w", entry.File, err)
+		}
+	case !explicit && len(entry.Sources) > 0:
+		for _, source := range entry.Sources {
+			dataset.Sources = append(dataset.Sources, graph.Node(source))
+		}
+	default:
+		dataset.Sources = []graph.Node{graph.Node(*source)}
+	}
+	for _, source := range dataset.Sources {
+		if int(source) < 0 || int(source) >= g.Order() {
+			return Dataset{}, close, fmt.Errorf("source %v outside of %v", source, entry.File)
+		}
+	}
+
+	return dataset, close, nil
+}
+
+// LoadDatasets loads the datasets named by args and validates them against
+// -manifest, all datasets are loaded before reporting validation errors.
+func LoadDatasets(args []string) ([]Dataset, func(), error) {
+	var closers []func()
+	closeAll := func() {
+		for _, close := range closers {
+			close()
+		}
+	}
+
+	m, err := ReadManifest()
+	if err != nil {
+		return nil, closeAll, err
+	}
+
+	// the parent process passes the checksums to -isolate and -ranks processes
+	var known []string
+	if *checksums != "" {
+		known = strings.Split(*checksums, ",")
+	}
+
+	var datasets []Dataset
+	var invalid []string
+	for i, entry := range m.Entries(args) {
+		fmt.Fprintln(os.Stderr, "# Loading dataset ", entry.File)
+		checksum := ""
+		if i < len(known) {
+			checksum = known[i]
+		}
+		dataset, close, err := LoadDataset(entry, checksum)
+		closers = append(closers, close)
+		if err != nil {
+			return nil, closeAll, err
+		}
+		for _, err := range dataset.Validate() {
+			invalid = append(invalid, err.Error())
+		}
+		datasets = append(datasets, dataset)
+	}
+	if len(invalid) > 0 {
+		return nil, closeAll, fmt.Errorf("invalid datasets:\n\t%v", strings.Join(invalid, "\n\t"))
+	}
+	return datasets, closeAll, nil
+}
+
+// LoadVerifyGraph loads the graph for checking approaches before benchmarking.
+func LoadVerifyGraph() (*graph.Graph, graph.Node, func(), error) {
+	m, err := ReadManifest()
+	if err != nil {
+		return nil, 0, func() {}, err
+	}
+	entry, source, err := m.VerifyEntry()
+	if err != nil {
+		return nil, 0, func() {}, err
+	}
+	g, close, err := entry.LoadGraph()
+	if err == nil && int(source) >= g.Order() {
+		err = fmt.Errorf("source %v outside of %v", source, entry.File)
+	}
+	return g, source, close, err
+}
+
+// Validate compares the loaded dataset against the manifest.
+func (dataset *Dataset) Validate() []error {
+	entry := &dataset.Entry
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%v: "+format, append([]interface{}{dataset.Name}, args...)...))
+	}
+
+	if entry.Checksum != "" && entry.Checksum != dataset.Info.Checksum {
+		fail("sha256 %v, expected %v", dataset.Info.Checksum, entry.Checksum)
+	}
+	if entry.Nodes != 0 && entry.Nodes != dataset.Info.Nodes {
+		fail("%v nodes, expected %v", dataset.Info.Nodes, entry.Nodes)
+	}
+	if entry.Edges != 0 && entry.Edges != dataset.Info.Edges {
+		fail("%v edges, expected %v", dataset.Info.Edges, entry.Edges)
+	}
+
+	if len(entry.Levels) > 0 {
+		source := verifySource
+		if len(entry.Sources) > 0 {
+			source = entry.Sources[0]
+		}
+		if source >= dataset.Graph.Order() {
+			fail("source %v outside of graph", source)
+			return errs
+		}
+
+		levels := LevelHistogram(dataset.Graph, graph.Node(source))
+		if !equalInts(levels, entry.Levels) {
+			fail("level histogram from %v is %v, expected %v", source, levels, entry.Levels)
+		}
+	}
+	return errs
+}
+
+// LevelHistogram counts the nodes at each level searching from source.
+func LevelHistogram(g *graph.Graph, source graph.Node) []int {
+	levels := make([]int, g.Order())
+	s00_baseline.BreadthFirst(g, source, levels)
+
+	var histogram []int
+	for _, level := range levels {
+		if level == 0 {
+			continue
+		}
+		for len(histogram) < level {
+			histogram = append(histogram, 0)
+		}
+		histogram[level-1]++
+	}
+	return histogram
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteManifest describes the files as a manifest, which can be used
+// as a starting point for -manifest.
+func WriteManifest(args []string) int {
+	flags := flag.NewFlagSet("manifest", flag.ExitOnError)
+	source := flags.Int("source", verifySource, "source node for the level histogram")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: a-tale-of-bfs manifest [flags] data/graph.txt ... > datasets.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	m := Manifest{}
+	for _, file := range flags.Args() {
+		entry := ManifestEntry{Name: removeExt(filepath.Base(file)), File: file}
+		g, close, err := entry.LoadGraph()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		stat, err := os.Stat(file)
+		if err == nil {
+			entry.Checksum, err = measure.Checksum(file)
+		}
+		if err != nil {
+			close()
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		entry.Size, entry.ModTime = stat.Size(), stat.ModTime()
+		if *source < 0 || *source >= g.Order() {
+			close()
+			fmt.Fprintf(os.Stderr, "source %v outside of %v\n", *source, file)
+			return 2
+		}
+		entry.Format = strings.TrimPrefix(filepath.Ext(file), ".")
+		entry.Nodes, entry.Edges = g.Order(), g.Size()
+		entry.Sources = []int{*source}
+		entry.Levels = LevelHistogram(g, graph.Node(*source))
+		close()
+
+		if m.Verify == "" {
+			m.Verify = entry.Name
+		}
+		m.Datasets = append(m.Datasets, entry)
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	fmt.Println(string(data))
+	return 0
+}
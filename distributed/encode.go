@@ -0,0 +1,61 @@
+package distributed
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+var errCorrupt = errors.New("distributed: corrupt message")
+
+// encodeNodes appends sorted nodes as varint deltas,
+// duplicate nodes are written only once.
+func encodeNodes(data []byte, nodes []graph.Node) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	prev := uint64(0)
+	for i, node := range nodes {
+		if i > 0 && uint64(node) == prev {
+			continue
+		}
+		n := binary.PutUvarint(tmp[:], uint64(node)-prev)
+		data = append(data, tmp[:n]...)
+		prev = uint64(node)
+	}
+	return data
+}
+
+func decodeNodes(data []byte, nodes []graph.Node) ([]graph.Node, error) {
+	prev := uint64(0)
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nodes, errCorrupt
+		}
+		data = data[n:]
+		prev += delta
+		nodes = append(nodes, graph.Node(prev))
+	}
+	return nodes, nil
+}
+
+func encodeLevels(data []byte, level []int) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	for _, v := range level {
+		n := binary.PutUvarint(tmp[:], uint64(v))
+		data = append(data, tmp[:n]...)
+	}
+	return data
+}
+
+func decodeLevels(data []byte, level []int) error {
+	for i := range level {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errCorrupt
+		}
+		data = data[n:]
+		level[i] = int(v)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package distributed
+
+import "github.com/egonelbre/a-tale-of-bfs/graph"
+
+// Partition is a contiguous range of nodes owned by a rank.
+type Partition struct {
+	Rank, Ranks int
+	BlockSize   int
+	Low, High   graph.Node
+}
+
+// NewPartition splits order nodes into equal blocks over ranks.
+func NewPartition(order, ranks, rank int) Partition {
+	blockSize := (order + ranks - 1) / ranks
+	if blockSize == 0 {
+		blockSize = 1
+	}
+
+	low, high := rank*blockSize, (rank+1)*blockSize
+	if low > order {
+		low = order
+	}
+	if high > order {
+		high = order
+	}
+
+	return Partition{
+		Rank:      rank,
+		Ranks:     ranks,
+		BlockSize: blockSize,
+		Low:       graph.Node(low),
+		High:      graph.Node(high),
+	}
+}
+
+func (part Partition) Owner(node graph.Node) int { return int(node) / part.BlockSize }
+func (part Partition) Owns(node graph.Node) bool { return part.Low <= node && node < part.High }
+func (part Partition) Len() int                  { return int(part.High - part.Low) }
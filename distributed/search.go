@@ -0,0 +1,176 @@
+package distributed
+
+import (
+	"sync"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// BreadthFirst searches the part of the graph owned by the rank of t.
+//
+// Every rank must call BreadthFirst with the same graph and source,
+// level is indexed by node and only the entries owned by the rank are written.
+// Nodes discovered in other partitions are sent to their owners in sorted batches.
+func BreadthFirst(g *graph.Graph, source graph.Node, level []int, t Transport) error {
+	if len(level) != g.Order() {
+		panic("invalid level length")
+	}
+
+	part := NewPartition(g.Order(), t.Ranks(), t.Rank())
+	visited := NewNodeSet(part.Len())
+
+	currentLevel := make([]graph.Node, 0, part.Len())
+	nextLevel := make([]graph.Node, 0, part.Len())
+	outgoing := make([][]graph.Node, part.Ranks)
+	var scratch []graph.Node
+
+	visit := func(node graph.Node) {
+		if !visited.Contains(node - part.Low) {
+			visited.Add(node - part.Low)
+			nextLevel = append(nextLevel, node)
+		}
+	}
+
+	if part.Owns(source) {
+		level[source] = 1
+		visited.Add(source - part.Low)
+		currentLevel = append(currentLevel, source)
+	}
+
+	levelNumber := 2
+
+	for {
+		for _, node := range currentLevel {
+			for _, neighbor := range g.Neighbors(node) {
+				if part.Owns(neighbor) {
+					visit(neighbor)
+				} else {
+					owner := part.Owner(neighbor)
+					outgoing[owner] = append(outgoing[owner], neighbor)
+				}
+			}
+		}
+
+		for to, nodes := range outgoing {
+			if to == part.Rank {
+				continue
+			}
+			if cap(scratch) < len(nodes) {
+				scratch = make([]graph.Node, len(nodes))
+			}
+			graph.SortNodes(nodes, scratch[:len(nodes)])
+			if err := t.Send(to, encodeNodes(nil, nodes)); err != nil {
+				return err
+			}
+			outgoing[to] = nodes[:0]
+		}
+
+		for from := 0; from < part.Ranks; from++ {
+			if from == part.Rank {
+				continue
+			}
+			data, err := t.Recv(from)
+			if err != nil {
+				return err
+			}
+			scratch, err = decodeNodes(data, scratch[:0])
+			if err != nil {
+				return err
+			}
+			for _, node := range scratch {
+				visit(node)
+			}
+		}
+
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
+
+		for _, neighbor := range nextLevel {
+			level[neighbor] = levelNumber
+		}
+
+		active, err := anyActive(t, len(nextLevel) > 0)
+		if err != nil || !active {
+			return err
+		}
+
+		levelNumber++
+		currentLevel = currentLevel[:0:cap(currentLevel)]
+		currentLevel, nextLevel = nextLevel, currentLevel
+	}
+}
+
+// anyActive returns whether any of the ranks is active.
+func anyActive(t Transport, active bool) (bool, error) {
+	flag := []byte{0}
+	if active {
+		flag[0] = 1
+	}
+
+	for to := 0; to < t.Ranks(); to++ {
+		if to != t.Rank() {
+			if err := t.Send(to, flag); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	for from := 0; from < t.Ranks(); from++ {
+		if from == t.Rank() {
+			continue
+		}
+		data, err := t.Recv(from)
+		if err != nil {
+			return false, err
+		}
+		if len(data) != 1 {
+			return false, errCorrupt
+		}
+		active = active || data[0] != 0
+	}
+
+	return active, nil
+}
+
+// Gather collects the levels owned by other ranks to rank 0.
+func Gather(level []int, t Transport) error {
+	if t.Rank() != 0 {
+		part := NewPartition(len(level), t.Ranks(), t.Rank())
+		return t.Send(0, encodeLevels(nil, level[part.Low:part.High]))
+	}
+
+	for from := 1; from < t.Ranks(); from++ {
+		part := NewPartition(len(level), t.Ranks(), from)
+		data, err := t.Recv(from)
+		if err != nil {
+			return err
+		}
+		if err := decodeLevels(data, level[part.Low:part.High]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Simulate runs BreadthFirst with ranks communicating over channels
+// and returns the error of the first failing rank.
+func Simulate(g *graph.Graph, source graph.Node, level []int, ranks int) error {
+	transports := NewChanNetwork(ranks)
+	errs := make([]error, ranks)
+
+	var wg sync.WaitGroup
+	wg.Add(ranks)
+	for rank, t := range transports {
+		go func(rank int, t Transport) {
+			defer wg.Done()
+			errs[rank] = BreadthFirst(g, source, level, t)
+		}(rank, t)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
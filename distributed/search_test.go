@@ -0,0 +1,146 @@
+package distributed
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+func randomGraph(nodes, edges int) *graph.Graph {
+	rng := rand.New(rand.NewSource(int64(nodes)))
+	var list []graph.Edge
+	for i := 0; i < edges; i++ {
+		a, b := graph.Node(rng.Intn(nodes)), graph.Node(rng.Intn(nodes))
+		list = append(list, graph.Edge{From: a, To: b}, graph.Edge{From: b, To: a})
+	}
+	return graph.FromEdges(nodes, list)
+}
+
+func TestSimulate(t *testing.T) {
+	g := randomGraph(1000, 3000)
+
+	expected := make([]int, g.Order())
+	s00_baseline.BreadthFirst(g, 3, expected)
+
+	for ranks := 1; ranks <= 5; ranks++ {
+		level := make([]int, g.Order())
+		if err := Simulate(g, 3, level, ranks); err != nil {
+			t.Fatalf("%d ranks: %v", ranks, err)
+		}
+		if !reflect.DeepEqual(level, expected) {
+			t.Errorf("%d ranks: levels differ", ranks)
+		}
+	}
+}
+
+func TestTCP(t *testing.T) {
+	const ranks = 3
+	g := randomGraph(500, 1500)
+
+	expected := make([]int, g.Order())
+	s00_baseline.BreadthFirst(g, 0, expected)
+
+	var listeners []net.Listener
+	var addrs []string
+	for i := 0; i < ranks; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			t.Skip(err)
+		}
+		listeners = append(listeners, ln)
+		addrs = append(addrs, ln.Addr().String())
+	}
+
+	levels := make([][]int, ranks)
+	errs := make([]error, ranks)
+
+	var wg sync.WaitGroup
+	wg.Add(ranks)
+	for rank := 0; rank < ranks; rank++ {
+		go func(rank int) {
+			defer wg.Done()
+			transport, err := ConnectTCP(rank, listeners[rank], addrs)
+			if err != nil {
+				errs[rank] = err
+				return
+			}
+			defer transport.Close()
+
+			levels[rank] = make([]int, g.Order())
+			if err := BreadthFirst(g, 0, levels[rank], transport); err != nil {
+				errs[rank] = err
+				return
+			}
+			errs[rank] = Gather(levels[rank], transport)
+		}(rank)
+	}
+	wg.Wait()
+
+	for rank, err := range errs {
+		if err != nil {
+			t.Fatalf("rank %d: %v", rank, err)
+		}
+	}
+	if !reflect.DeepEqual(levels[0], expected) {
+		t.Error("levels differ")
+	}
+}
+
+func TestConnectTCPFailure(t *testing.T) {
+	defer func(timeout time.Duration) { DialTimeout = timeout }(DialTimeout)
+	DialTimeout = 200 * time.Millisecond
+
+	// rank 0 isn't listening, so rank 1 fails to connect
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	down.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// rank 2 has already connected to rank 1
+	peer, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	var id [8]byte
+	id[0] = 2
+	if _, err := peer.Write(id[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	addrs := []string{down.Addr().String(), ln.Addr().String(), peer.LocalAddr().String()}
+	if _, err := ConnectTCP(1, ln, addrs); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	peer.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := peer.Read(id[:]); err != io.EOF {
+		t.Errorf("accepted connection not closed: %v", err)
+	}
+}
+
+func TestEncodeNodes(t *testing.T) {
+	nodes := []graph.Node{0, 1, 1, 5, 300, 300, 70000}
+	decoded, err := decodeNodes(encodeNodes(nil, nodes), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []graph.Node{0, 1, 5, 300, 70000}; !reflect.DeepEqual(decoded, exp) {
+		t.Errorf("got %v exp %v", decoded, exp)
+	}
+}
@@ -0,0 +1,208 @@
+package distributed
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// DialTimeout is how long DialTCP waits for other ranks to start listening.
+var DialTimeout = 10 * time.Second
+
+type tcpTransport struct {
+	rank    int
+	conns   []net.Conn
+	readers []*bufio.Reader
+	queues  []chan []byte
+
+	writers sync.WaitGroup
+	closed  sync.Once
+	mu      sync.Mutex
+	err     error
+}
+
+// DialTCP connects rank to all other ranks listening on addrs.
+func DialTCP(rank int, addrs []string) (Transport, error) {
+	ln, err := net.Listen("tcp", addrs[rank])
+	if err != nil {
+		return nil, err
+	}
+	return ConnectTCP(rank, ln, addrs)
+}
+
+// ConnectTCP is DialTCP with the listener of rank already open,
+// the listener is closed once all ranks are connected.
+func ConnectTCP(rank int, ln net.Listener, addrs []string) (Transport, error) {
+	t := &tcpTransport{
+		rank:    rank,
+		conns:   make([]net.Conn, len(addrs)),
+		readers: make([]*bufio.Reader, len(addrs)),
+		queues:  make([]chan []byte, len(addrs)),
+	}
+
+	// higher ranks connect to us
+	accepted := make(chan error, 1)
+	go func() { accepted <- t.accept(ln) }()
+
+	// we connect to lower ranks
+	err := t.dial(addrs)
+	if err != nil {
+		// stops accepting, a pending handshake fails after DialTimeout
+		ln.Close()
+		<-accepted
+	} else {
+		err = <-accepted
+		ln.Close()
+	}
+	if err != nil {
+		for _, conn := range t.conns {
+			if conn != nil {
+				conn.Close()
+			}
+		}
+		return nil, err
+	}
+
+	for peer, conn := range t.conns {
+		if peer == rank {
+			continue
+		}
+		t.readers[peer] = bufio.NewReader(conn)
+		t.queues[peer] = make(chan []byte, 4)
+
+		t.writers.Add(1)
+		go t.write(conn, t.queues[peer])
+	}
+
+	return t, nil
+}
+
+// accept waits for the higher ranks to connect and introduce themselves.
+func (t *tcpTransport) accept(ln net.Listener) error {
+	for i := t.rank + 1; i < len(t.conns); i++ {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		var id [8]byte
+		conn.SetReadDeadline(time.Now().Add(DialTimeout))
+		_, err = io.ReadFull(conn, id[:])
+		conn.SetReadDeadline(time.Time{})
+
+		from := int(binary.LittleEndian.Uint64(id[:]))
+		if err == nil && (from <= t.rank || from >= len(t.conns) || t.conns[from] != nil) {
+			err = fmt.Errorf("distributed: unexpected rank %d", from)
+		}
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		t.conns[from] = conn
+	}
+	return nil
+}
+
+// dial connects to the lower ranks and introduces itself.
+func (t *tcpTransport) dial(addrs []string) error {
+	for to := 0; to < t.rank; to++ {
+		conn, err := dialRetry(addrs[to])
+		if err != nil {
+			return err
+		}
+		var id [8]byte
+		binary.LittleEndian.PutUint64(id[:], uint64(t.rank))
+		if _, err := conn.Write(id[:]); err != nil {
+			conn.Close()
+			return err
+		}
+		t.conns[to] = conn
+	}
+	return nil
+}
+
+func dialRetry(addr string) (net.Conn, error) {
+	start := time.Now()
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil || time.Since(start) > DialTimeout {
+			return conn, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// write sends queued messages, so that Send does not block
+// when both sides are sending at the same time.
+func (t *tcpTransport) write(conn net.Conn, queue chan []byte) {
+	defer t.writers.Done()
+
+	w := bufio.NewWriter(conn)
+	for data := range queue {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(len(data)))
+		w.Write(size[:])
+		w.Write(data)
+		if err := w.Flush(); err != nil {
+			t.fail(err)
+		}
+	}
+}
+
+func (t *tcpTransport) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *tcpTransport) Rank() int  { return t.rank }
+func (t *tcpTransport) Ranks() int { return len(t.conns) }
+
+func (t *tcpTransport) Send(to int, data []byte) error {
+	t.mu.Lock()
+	err := t.err
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	t.queues[to] <- data
+	return nil
+}
+
+func (t *tcpTransport) Recv(from int) ([]byte, error) {
+	var size [8]byte
+	if _, err := io.ReadFull(t.readers[from], size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint64(size[:]))
+	_, err := io.ReadFull(t.readers[from], data)
+	return data, err
+}
+
+func (t *tcpTransport) Close() error {
+	t.closed.Do(func() {
+		for _, queue := range t.queues {
+			if queue != nil {
+				close(queue)
+			}
+		}
+		t.writers.Wait()
+
+		for _, conn := range t.conns {
+			if conn != nil {
+				conn.Close()
+			}
+		}
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
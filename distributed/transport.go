@@ -0,0 +1,49 @@
+package distributed
+
+// Transport exchanges messages between ranks.
+//
+// Send takes ownership of data. Messages from a single rank are received
+// in the order they were sent.
+type Transport interface {
+	Rank() int
+	Ranks() int
+	Send(to int, data []byte) error
+	Recv(from int) ([]byte, error)
+	Close() error
+}
+
+type chanTransport struct {
+	rank  int
+	links [][]chan []byte
+}
+
+// NewChanNetwork creates in-process transports for ranks.
+func NewChanNetwork(ranks int) []Transport {
+	links := make([][]chan []byte, ranks)
+	for from := range links {
+		links[from] = make([]chan []byte, ranks)
+		for to := range links[from] {
+			links[from][to] = make(chan []byte, 4)
+		}
+	}
+
+	transports := make([]Transport, ranks)
+	for rank := range transports {
+		transports[rank] = &chanTransport{rank: rank, links: links}
+	}
+	return transports
+}
+
+func (t *chanTransport) Rank() int  { return t.rank }
+func (t *chanTransport) Ranks() int { return len(t.links) }
+
+func (t *chanTransport) Send(to int, data []byte) error {
+	t.links[t.rank][to] <- data
+	return nil
+}
+
+func (t *chanTransport) Recv(from int) ([]byte, error) {
+	return <-t.links[from][t.rank], nil
+}
+
+func (t *chanTransport) Close() error { return nil }
@@ -0,0 +1,160 @@
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// Graph is a mutable graph, it consists of an immutable CSR base
+// and per-node delta logs of inserted and deleted neighbors.
+type Graph struct {
+	Base *graph.Graph
+
+	Inserted [][]graph.Node
+	Deleted  [][]graph.Node
+
+	// CompactRatio is the ratio of pending delta entries to base edges
+	// after which Apply compacts the deltas back into the base.
+	CompactRatio float64
+
+	pending int
+}
+
+// Batch is a set of edge updates, deletions are applied before insertions.
+type Batch struct {
+	Insert []graph.Edge
+	Delete []graph.Edge
+}
+
+func New(base *graph.Graph) *Graph {
+	return &Graph{
+		Base:         base,
+		Inserted:     make([][]graph.Node, base.Order()),
+		Deleted:      make([][]graph.Node, base.Order()),
+		CompactRatio: 0.25,
+	}
+}
+
+// Order returns the number of nodes.
+func (g *Graph) Order() int { return g.Base.Order() }
+
+// Pending returns the number of delta entries not yet compacted.
+func (g *Graph) Pending() int { return g.pending }
+
+// Neighbors returns the neighbors of n in the combined view,
+// buf is reused for nodes that have pending deltas.
+func (g *Graph) Neighbors(n graph.Node, buf *[]graph.Node) []graph.Node {
+	base := g.Base.Neighbors(n)
+	inserted, deleted := g.Inserted[n], g.Deleted[n]
+	if len(inserted) == 0 && len(deleted) == 0 {
+		return base
+	}
+
+	*buf = (*buf)[:0]
+	for _, neighbor := range base {
+		if !contains(deleted, neighbor) {
+			*buf = append(*buf, neighbor)
+		}
+	}
+	*buf = append(*buf, inserted...)
+	return *buf
+}
+
+// Apply applies the batch and compacts the graph when there are too many pending deltas.
+func (g *Graph) Apply(batch Batch) error {
+	for _, e := range batch.Delete {
+		if err := g.check(e); err != nil {
+			return err
+		}
+	}
+	for _, e := range batch.Insert {
+		if err := g.check(e); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range batch.Delete {
+		g.delete(e)
+	}
+	for _, e := range batch.Insert {
+		g.insert(e)
+	}
+
+	if float64(g.pending) > g.CompactRatio*float64(g.Base.Size()) {
+		g.Compact()
+	}
+	return nil
+}
+
+func (g *Graph) check(e graph.Edge) error {
+	if int(e.From) >= g.Order() || int(e.To) >= g.Order() {
+		return fmt.Errorf("dynamic: edge %v->%v outside of graph with %v nodes", e.From, e.To, g.Order())
+	}
+	return nil
+}
+
+func (g *Graph) delete(e graph.Edge) {
+	if i := index(g.Inserted[e.From], e.To); i >= 0 {
+		g.Inserted[e.From] = remove(g.Inserted[e.From], i)
+		g.pending--
+		return
+	}
+	if contains(g.Deleted[e.From], e.To) || !contains(g.Base.Neighbors(e.From), e.To) {
+		return
+	}
+	g.Deleted[e.From] = append(g.Deleted[e.From], e.To)
+	g.pending++
+}
+
+func (g *Graph) insert(e graph.Edge) {
+	if i := index(g.Deleted[e.From], e.To); i >= 0 {
+		g.Deleted[e.From] = remove(g.Deleted[e.From], i)
+		g.pending--
+		return
+	}
+	if contains(g.Inserted[e.From], e.To) || contains(g.Base.Neighbors(e.From), e.To) {
+		return
+	}
+	g.Inserted[e.From] = append(g.Inserted[e.From], e.To)
+	g.pending++
+}
+
+// Compact rebuilds the base from the combined view and clears the deltas.
+func (g *Graph) Compact() {
+	if g.pending == 0 {
+		return
+	}
+
+	base := &graph.Graph{}
+	base.List = make([]graph.Node, 0, g.Base.Size()+g.pending)
+	base.Span = make([]uint64, 1, g.Order()+1)
+
+	var buf []graph.Node
+	for n := 0; n < g.Order(); n++ {
+		base.List = append(base.List, g.Neighbors(graph.Node(n), &buf)...)
+		base.Span = append(base.Span, uint64(len(base.List)))
+
+		g.Inserted[n] = g.Inserted[n][:0]
+		g.Deleted[n] = g.Deleted[n][:0]
+	}
+
+	g.Base = base
+	g.pending = 0
+}
+
+func index(nodes []graph.Node, node graph.Node) int {
+	for i, n := range nodes {
+		if n == node {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(nodes []graph.Node, node graph.Node) bool { return index(nodes, node) >= 0 }
+
+func remove(nodes []graph.Node, i int) []graph.Node {
+	nodes[i] = nodes[len(nodes)-1]
+	return nodes[:len(nodes)-1]
+}
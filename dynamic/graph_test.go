@@ -0,0 +1,128 @@
+package dynamic
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+type edgeSet map[graph.Edge]bool
+
+func (set edgeSet) graph(nodes int) *graph.Graph {
+	edges := make([]graph.Edge, 0, len(set))
+	for e := range set {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, k int) bool {
+		if edges[i].From == edges[k].From {
+			return edges[i].To < edges[k].To
+		}
+		return edges[i].From < edges[k].From
+	})
+	return graph.FromEdges(nodes, edges)
+}
+
+func randomEdge(rng *rand.Rand, nodes int) graph.Edge {
+	return graph.Edge{From: graph.Node(rng.Intn(nodes)), To: graph.Node(rng.Intn(nodes))}
+}
+
+func reverse(e graph.Edge) graph.Edge { return graph.Edge{From: e.To, To: e.From} }
+
+func sortedNeighbors(nodes []graph.Node) []graph.Node {
+	r := append([]graph.Node{}, nodes...)
+	sort.Slice(r, func(i, k int) bool { return r[i] < r[k] })
+	return r
+}
+
+func TestUpdates(t *testing.T) {
+	const nodes = 300
+
+	for _, ratio := range []float64{0, 0.1, 1e9} {
+		rng := rand.New(rand.NewSource(1))
+
+		reference := edgeSet{}
+		for i := 0; i < 600; i++ {
+			e := randomEdge(rng, nodes)
+			reference[e], reference[reverse(e)] = true, true
+		}
+
+		g := New(reference.graph(nodes))
+		g.CompactRatio = ratio
+
+		for round := 0; round < 30; round++ {
+			var batch Batch
+			for i := 0; i < 40; i++ {
+				e := randomEdge(rng, nodes)
+				batch.Insert = append(batch.Insert, e, reverse(e))
+			}
+			for i := 0; i < 40; i++ {
+				e := randomEdge(rng, nodes)
+				if rng.Intn(2) == 0 {
+					// delete an existing edge
+					for existing := range reference {
+						e = existing
+						break
+					}
+				}
+				batch.Delete = append(batch.Delete, e, reverse(e))
+			}
+
+			for _, e := range batch.Delete {
+				delete(reference, e)
+			}
+			for _, e := range batch.Insert {
+				reference[e] = true
+			}
+
+			if err := g.Apply(batch); err != nil {
+				t.Fatal(err)
+			}
+
+			rebuilt := reference.graph(nodes)
+
+			var buf []graph.Node
+			for n := 0; n < nodes; n++ {
+				got := sortedNeighbors(g.Neighbors(graph.Node(n), &buf))
+				exp := rebuilt.Neighbors(graph.Node(n))
+				if len(got) != len(exp) || (len(got) > 0 && !reflect.DeepEqual(got, exp)) {
+					t.Fatalf("ratio %v round %v: node %v neighbors got %v exp %v", ratio, round, n, got, exp)
+				}
+			}
+
+			source := graph.Node(rng.Intn(nodes))
+			expected := make([]int, nodes)
+			s00_baseline.BreadthFirst(rebuilt, source, expected)
+
+			level := make([]int, nodes)
+			BreadthFirst(g, source, level)
+			if !reflect.DeepEqual(level, expected) {
+				t.Fatalf("ratio %v round %v: BreadthFirst mismatch", ratio, round)
+			}
+
+			for _, procs := range []int{1, 4} {
+				level := make([]int, nodes)
+				BreadthFirstParallel(g, source, level, procs)
+				if !reflect.DeepEqual(level, expected) {
+					t.Fatalf("ratio %v round %v: BreadthFirstParallel %dx mismatch", ratio, round, procs)
+				}
+			}
+		}
+
+		g.Compact()
+		if g.Pending() != 0 {
+			t.Fatalf("ratio %v: pending %v after compaction", ratio, g.Pending())
+		}
+	}
+}
+
+func TestApplyOutside(t *testing.T) {
+	g := New(graph.FromEdges(2, nil))
+	err := g.Apply(Batch{Insert: []graph.Edge{{From: 0, To: 2}}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
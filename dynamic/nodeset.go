@@ -0,0 +1,55 @@
+package dynamic
+
+import (
+	"sync/atomic"
+
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+const (
+	bucket_bits = 5
+	bucket_size = 1 << 5
+	bucket_mask = bucket_size - 1
+)
+
+type NodeSet []uint32
+
+func NewNodeSet(size int) NodeSet {
+	return NodeSet(make([]uint32, (size+31)/32))
+}
+
+// NewLocalNodeSet splits the buckets between procs workers for first-touch.
+func NewLocalNodeSet(size, procs int) NodeSet {
+	return NodeSet(affinity.Uint32s(procs, (size+31)/32))
+}
+
+func (set NodeSet) Offset(node graph.Node) (bucket graph.Node, bit uint32) {
+	bucket = node >> bucket_bits
+	bit = uint32(1 << (node & bucket_mask))
+	return bucket, bit
+}
+
+func (set NodeSet) TryAdd(node graph.Node) bool {
+	bucket, bit := set.Offset(node)
+	addr := &set[bucket]
+retry:
+	old := atomic.LoadUint32(addr)
+	if old&bit != 0 {
+		return false
+	}
+	if atomic.CompareAndSwapUint32(addr, old, old|bit) {
+		return true
+	}
+	goto retry
+}
+
+func (set NodeSet) Add(node graph.Node) {
+	bucket, bit := set.Offset(node)
+	set[bucket] |= bit
+}
+
+func (set NodeSet) Contains(node graph.Node) bool {
+	bucket, bit := set.Offset(node)
+	return set[bucket]&bit != 0
+}
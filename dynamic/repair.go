@@ -0,0 +1,127 @@
+package dynamic
+
+import "github.com/egonelbre/a-tale-of-bfs/graph"
+
+// Repair updates level, computed from source before batch was applied
+// to g, so that it matches BreadthFirst on the updated graph.
+//
+// Nodes that may have lost their path to source are invalidated and
+// together with the targets of inserted edges used to propagate new
+// levels. When more than limit nodes are affected, level is recomputed
+// from scratch. It returns whether level was recomputed.
+//
+// Handling deletions assumes a symmetric adjacency, as in the datasets.
+func Repair(g *Graph, source graph.Node, level []int, batch Batch, limit int) (recomputed bool) {
+	if len(level) != g.Order() {
+		panic("invalid level length")
+	}
+
+	affected := 0
+	var buf []graph.Node
+	var buckets levelBuckets
+
+	// invalidate nodes that lost all their parents
+	invalid := NewNodeSet(g.Order())
+	var invalidated []graph.Node
+	for _, e := range batch.Delete {
+		if level[e.From] > 0 && level[e.To] == level[e.From]+1 {
+			buckets.Push(level[e.To], e.To)
+		}
+	}
+	for L := 0; L < len(buckets); L++ {
+		for _, node := range buckets[L] {
+			if invalid.Contains(node) || hasParent(g, level, invalid, node, &buf) {
+				continue
+			}
+			invalid.Add(node)
+			invalidated = append(invalidated, node)
+
+			affected++
+			if affected > limit {
+				recompute(g, source, level)
+				return true
+			}
+
+			for _, child := range g.Neighbors(node, &buf) {
+				if level[child] == L+1 {
+					buckets.Push(L+1, child)
+				}
+			}
+		}
+		buckets[L] = buckets[L][:0]
+	}
+
+	// seed the invalidated nodes from their valid neighbors
+	for _, node := range invalidated {
+		level[node] = 0
+	}
+	for _, node := range invalidated {
+		best := 0
+		for _, neighbor := range g.Neighbors(node, &buf) {
+			if level[neighbor] > 0 && (best == 0 || level[neighbor]+1 < best) {
+				best = level[neighbor] + 1
+			}
+		}
+		if best > 0 {
+			level[node] = best
+			buckets.Push(best, node)
+		}
+	}
+
+	// seed the targets of inserted edges
+	for _, e := range batch.Insert {
+		if level[e.From] > 0 && (level[e.To] == 0 || level[e.To] > level[e.From]+1) {
+			level[e.To] = level[e.From] + 1
+			buckets.Push(level[e.To], e.To)
+		}
+	}
+
+	// propagate the new levels in increasing order
+	for L := 0; L < len(buckets); L++ {
+		for _, node := range buckets[L] {
+			if level[node] != L {
+				continue
+			}
+			for _, neighbor := range g.Neighbors(node, &buf) {
+				if level[neighbor] == 0 || level[neighbor] > L+1 {
+					level[neighbor] = L + 1
+					buckets.Push(L+1, neighbor)
+
+					affected++
+					if affected > limit {
+						recompute(g, source, level)
+						return true
+					}
+				}
+			}
+		}
+		buckets[L] = nil
+	}
+
+	return false
+}
+
+func hasParent(g *Graph, level []int, invalid NodeSet, node graph.Node, buf *[]graph.Node) bool {
+	for _, parent := range g.Neighbors(node, buf) {
+		if level[parent] == level[node]-1 && !invalid.Contains(parent) {
+			return true
+		}
+	}
+	return false
+}
+
+func recompute(g *Graph, source graph.Node, level []int) {
+	for i := range level {
+		level[i] = 0
+	}
+	BreadthFirst(g, source, level)
+}
+
+type levelBuckets [][]graph.Node
+
+func (buckets *levelBuckets) Push(level int, node graph.Node) {
+	for len(*buckets) <= level {
+		*buckets = append(*buckets, nil)
+	}
+	(*buckets)[level] = append((*buckets)[level], node)
+}
@@ -0,0 +1,72 @@
+package dynamic
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+func TestRepair(t *testing.T) {
+	const nodes = 400
+
+	for _, limit := range []int{0, 20, nodes} {
+		rng := rand.New(rand.NewSource(2))
+
+		reference := edgeSet{}
+		for i := 0; i < 500; i++ {
+			e := randomEdge(rng, nodes)
+			reference[e], reference[reverse(e)] = true, true
+		}
+
+		g := New(reference.graph(nodes))
+		source := graph.Node(0)
+		level := make([]int, nodes)
+		BreadthFirst(g, source, level)
+
+		recomputed := 0
+		for round := 0; round < 50; round++ {
+			var batch Batch
+			for i := rng.Intn(10); i > 0; i-- {
+				e := randomEdge(rng, nodes)
+				batch.Insert = append(batch.Insert, e, reverse(e))
+			}
+			for i := rng.Intn(10); i > 0; i-- {
+				for e := range reference {
+					batch.Delete = append(batch.Delete, e, reverse(e))
+					break
+				}
+			}
+
+			for _, e := range batch.Delete {
+				delete(reference, e)
+			}
+			for _, e := range batch.Insert {
+				reference[e] = true
+			}
+			if err := g.Apply(batch); err != nil {
+				t.Fatal(err)
+			}
+
+			if Repair(g, source, level, batch, limit) {
+				recomputed++
+			}
+
+			expected := make([]int, nodes)
+			s00_baseline.BreadthFirst(reference.graph(nodes), source, expected)
+			if !reflect.DeepEqual(level, expected) {
+				for i := range level {
+					if level[i] != expected[i] {
+						t.Fatalf("limit %v round %v: node %v got level %v exp %v", limit, round, i, level[i], expected[i])
+					}
+				}
+			}
+		}
+
+		if limit == nodes && recomputed > 0 {
+			t.Errorf("limit %v: recomputed %v times", limit, recomputed)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package dynamic
+
+import (
+	"runtime"
+
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// BreadthFirst searches the combined view of g, it is equivalent to 06_ordering.
+func BreadthFirst(g *Graph, source graph.Node, level []int) {
+	if len(level) != g.Order() {
+		panic("invalid level length")
+	}
+
+	visited := NewNodeSet(g.Order())
+
+	currentLevel := make([]graph.Node, 0, g.Order())
+	nextLevel := make([]graph.Node, 0, g.Order())
+
+	level[source] = 1
+	visited.Add(source)
+	currentLevel = append(currentLevel, source)
+
+	levelNumber := 2
+
+	var buf []graph.Node
+	for len(currentLevel) > 0 {
+		for _, node := range currentLevel {
+			for _, neighbor := range g.Neighbors(node, &buf) {
+				if !visited.Contains(neighbor) {
+					visited.Add(neighbor)
+					nextLevel = append(nextLevel, neighbor)
+				}
+			}
+		}
+
+		graph.SortNodes(nextLevel, currentLevel[:cap(currentLevel)])
+
+		for _, neighbor := range nextLevel {
+			level[neighbor] = levelNumber
+		}
+
+		levelNumber++
+		currentLevel = currentLevel[:0:cap(currentLevel)]
+		currentLevel, nextLevel = nextLevel, currentLevel
+	}
+}
+
+const (
+	ReadBlockSize  = 256
+	WriteBlockSize = 256
+	SentinelNode   = ^graph.Node(0)
+)
+
+type Frontier struct {
+	Nodes []graph.Node
+	Head  graph.Index
+}
+
+func (front *Frontier) NextRead() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, ReadBlockSize)
+	low = high - ReadBlockSize
+	if high > graph.Index(len(front.Nodes)) {
+		high = graph.Index(len(front.Nodes))
+	}
+	return
+}
+
+func (front *Frontier) NextWrite() (low, high graph.Index) {
+	high = graph.AddIndex(&front.Head, WriteBlockSize)
+	low = high - WriteBlockSize
+	return
+}
+
+func (front *Frontier) Write(low, high *graph.Index, v graph.Node) {
+	if *low >= *high {
+		*low, *high = front.NextWrite()
+	}
+	front.Nodes[*low] = v
+	*low += 1
+}
+
+func process(g *Graph, currentLevel, nextLevel *Frontier, visited NodeSet) {
+	writeLow, writeHigh := graph.Index(0), graph.Index(0)
+	var buf []graph.Node
+	for {
+		readLow, readHigh := currentLevel.NextRead()
+		if readLow >= readHigh {
+			break
+		}
+
+		for _, node := range currentLevel.Nodes[readLow:readHigh] {
+			if node == SentinelNode {
+				continue
+			}
+
+			for _, n := range g.Neighbors(node, &buf) {
+				if visited.TryAdd(n) {
+					nextLevel.Write(&writeLow, &writeHigh, n)
+				}
+			}
+		}
+	}
+
+	for i := writeLow; i < writeHigh; i += 1 {
+		nextLevel.Nodes[i] = SentinelNode
+	}
+}
+
+// BreadthFirstParallel searches the combined view of g, it is equivalent to 13_marking.
+func BreadthFirstParallel(g *Graph, source graph.Node, level []int, procs int) {
+	if len(level) != g.Order() {
+		panic("invalid level length")
+	}
+
+	visited := NewLocalNodeSet(g.Order(), procs)
+
+	maxSize := g.Order() + WriteBlockSize*procs
+
+	currentLevel := &Frontier{affinity.Nodes(procs, maxSize)[:0], 0}
+	nextLevel := &Frontier{affinity.Nodes(procs, maxSize), 0}
+
+	level[source] = 1
+	visited.TryAdd(source)
+	currentLevel.Nodes = append(currentLevel.Nodes, source)
+
+	levelNumber := 2
+
+	for len(currentLevel.Nodes) > 0 {
+		affinity.Run(procs, func(i int) {
+			runtime.LockOSThread()
+			process(g, currentLevel, nextLevel, visited)
+		})
+
+		affinity.BlockIter(int(nextLevel.Head), procs, func(low, high int) {
+			runtime.LockOSThread()
+			graph.SortNodes(nextLevel.Nodes[low:high], currentLevel.Nodes[low:high])
+			for _, neighbor := range nextLevel.Nodes[low:high] {
+				if neighbor == SentinelNode {
+					break
+				}
+				level[neighbor] = levelNumber
+			}
+		})
+
+		levelNumber++
+		currentLevel, nextLevel = nextLevel, currentLevel
+
+		currentLevel.Nodes = currentLevel.Nodes[:currentLevel.Head]
+		currentLevel.Head = 0
+
+		nextLevel.Nodes = nextLevel.Nodes[:cap(nextLevel.Nodes)]
+		nextLevel.Head = 0
+	}
+}
@@ -17,6 +17,6 @@ require (
 	github.com/loov/diagram v0.0.0-20200205133358-3c00c8e48506
 	github.com/montanaflynn/stats v0.5.0 // indirect
 	github.com/shawnsmithdev/zermelo v0.0.0-20190712023933-72892ed011e9
-	golang.org/x/sys v0.0.0-20200202164722-d101bd2416d5 // indirect
+	golang.org/x/sys v0.0.0-20200202164722-d101bd2416d5
 	gonum.org/v1/gonum v0.6.2
 )
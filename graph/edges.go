@@ -0,0 +1,40 @@
+package graph
+
+type Edge struct {
+	From, To Node
+}
+
+// FromEdges creates a graph with nodes and the directed edges,
+// neighbors keep the order they have in edges.
+func FromEdges(nodes int, edges []Edge) *Graph {
+	graph := &Graph{}
+	graph.List = make([]Node, len(edges))
+	graph.Span = make([]uint64, nodes+1)
+
+	for _, e := range edges {
+		graph.Span[e.From+1]++
+	}
+	for i := 1; i < len(graph.Span); i++ {
+		graph.Span[i] += graph.Span[i-1]
+	}
+
+	offset := make([]uint64, nodes)
+	copy(offset, graph.Span)
+	for _, e := range edges {
+		graph.List[offset[e.From]] = e.To
+		offset[e.From]++
+	}
+
+	return graph
+}
+
+// Edges returns all directed edges in the graph.
+func (graph *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, graph.Size())
+	for from := 0; from < graph.Order(); from++ {
+		for _, to := range graph.Neighbors(Node(from)) {
+			edges = append(edges, Edge{Node(from), to})
+		}
+	}
+	return edges
+}
@@ -1,7 +1,5 @@
 package graph
 
-type Node = uint32
-
 type Graph struct {
 	List []Node
 	Span []uint64
@@ -12,6 +10,15 @@ func (graph *Graph) Neighbors(n Node) []Node {
 	return graph.List[start:end]
 }
 
+// Order returns the number of nodes.
 func (graph *Graph) Order() int {
+	if len(graph.Span) == 0 {
+		return 0
+	}
+	return len(graph.Span) - 1
+}
+
+// Size returns the number of (directed) edges.
+func (graph *Graph) Size() int {
 	return len(graph.List)
 }
@@ -1,12 +1,78 @@
 package graph
 
 import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"os"
 	"unsafe"
 
 	mmap "github.com/edsrzf/mmap-go"
 )
 
+// datMagic starts a .dat file that records the node width in its header:
+//
+//	magic u64 | nodesize u64 | listlen u64 | spanlen u64 | list [listlen]Node | pad | span [spanlen]u64
+//
+// Files without the magic use the legacy layout with 32-bit nodes:
+//
+//	listlen u64 | spanlen u64 | list [listlen]u32 | span [spanlen]u64
+const datMagic = 0x6870617267736662 // "bfsgraph"
+
+var ErrNodeSize = errors.New("graph: file node size is larger than supported, build with -tags node64")
+
+type datHeader struct {
+	NodeSize int
+	ListLen  uint64
+	SpanLen  uint64
+
+	ListOffset int
+	SpanOffset int
+}
+
+func readHeader(data []byte) (datHeader, error) {
+	var h datHeader
+	if len(data) < 16 {
+		return h, errors.New("graph: file too short")
+	}
+
+	x := 0
+	next := func() uint64 {
+		v := binary.LittleEndian.Uint64(data[x : x+8])
+		x += 8
+		return v
+	}
+
+	if binary.LittleEndian.Uint64(data) != datMagic {
+		h.NodeSize = 4
+		h.ListLen = next()
+		h.SpanLen = next()
+		h.ListOffset = x
+		h.SpanOffset = x + 4*int(h.ListLen)
+	} else {
+		if len(data) < 32 {
+			return h, errors.New("graph: file too short")
+		}
+		next()
+		h.NodeSize = int(next())
+		h.ListLen = next()
+		h.SpanLen = next()
+		h.ListOffset = x
+		h.SpanOffset = align8(x + h.NodeSize*int(h.ListLen))
+	}
+
+	if h.NodeSize != 4 && h.NodeSize != 8 {
+		return h, fmt.Errorf("graph: invalid node size %d", h.NodeSize)
+	}
+	if h.SpanOffset+8*int(h.SpanLen) > len(data) {
+		return h, errors.New("graph: file too short")
+	}
+	return h, nil
+}
+
+func align8(x int) int { return (x + 7) &^ 7 }
+
 func LoadDAT(filename string) (*Graph, error) {
 	file, err := os.OpenFile(filename, os.O_RDONLY, 0644)
 	if err != nil {
@@ -20,29 +86,76 @@ func LoadDAT(filename string) (*Graph, error) {
 	}
 	defer data.Unmap()
 
-	var listlen uint64
-	var spanlen uint64
-
-	x := 0
-	copy((*[8]byte)(unsafe.Pointer(&listlen))[:], data[x:x+8])
-	x += 8
-	copy((*[8]byte)(unsafe.Pointer(&spanlen))[:], data[x:x+8])
-	x += 8
+	h, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.NodeSize > NodeSize {
+		return nil, ErrNodeSize
+	}
 
 	graph := &Graph{}
-	graph.List = make([]Node, listlen)
-	graph.Span = make([]uint64, spanlen)
+	graph.List = make([]Node, h.ListLen)
+	graph.Span = make([]uint64, h.SpanLen)
 
-	listdata := ((*[1 << 40]Node)(unsafe.Pointer(&data[x])))
-	copy(graph.List, listdata[:])
-	x += 4 * int(listlen)
+	if h.ListLen > 0 {
+		if h.NodeSize == NodeSize {
+			listdata := ((*[1 << 40]Node)(unsafe.Pointer(&data[h.ListOffset])))
+			copy(graph.List, listdata[:])
+		} else {
+			listdata := ((*[1 << 40]uint32)(unsafe.Pointer(&data[h.ListOffset])))
+			for i, v := range listdata[:h.ListLen] {
+				graph.List[i] = Node(v)
+			}
+		}
+	}
 
-	spandata := ((*[1 << 40]uint64)(unsafe.Pointer(&data[x])))
-	copy(graph.Span, spandata[:])
+	if h.SpanLen > 0 {
+		spandata := ((*[1 << 40]uint64)(unsafe.Pointer(&data[h.SpanOffset])))
+		copy(graph.Span, spandata[:])
+	}
 
 	return graph, nil
 }
 
+// WriteDat writes the graph with a header recording the node width.
 func WriteDat(filename string, g *Graph) error {
-	return nil
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	put := func(v uint64) {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], v)
+		w.Write(b[:])
+	}
+
+	put(datMagic)
+	put(NodeSize)
+	put(uint64(len(g.List)))
+	put(uint64(len(g.Span)))
+
+	for _, v := range g.List {
+		if NodeSize == 4 {
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], uint32(v))
+			w.Write(b[:])
+		} else {
+			put(uint64(v))
+		}
+	}
+	pad := align8(32+NodeSize*len(g.List)) - (32 + NodeSize*len(g.List))
+	w.Write(make([]byte, pad))
+
+	for _, v := range g.Span {
+		put(v)
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
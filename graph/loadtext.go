@@ -29,7 +29,7 @@ func ParseText(r io.Reader) (*Graph, error) {
 			break
 		}
 
-		value, err := strconv.ParseUint(line, 10, 32)
+		value, err := strconv.ParseUint(line, 10, 64)
 		if err != nil {
 			return nil, err
 		}
@@ -40,7 +40,7 @@ func ParseText(r io.Reader) (*Graph, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		value, err := strconv.ParseUint(line, 10, 32)
+		value, err := strconv.ParseUint(line, 10, NodeSize*8)
 		if err != nil {
 			return nil, err
 		}
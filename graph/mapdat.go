@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+
+	mmap "github.com/edsrzf/mmap-go"
+)
+
+// Mapped is a graph whose adjacency list is backed by a memory mapped .dat file.
+type Mapped struct {
+	Graph
+	data mmap.MMap
+}
+
+// MapDAT maps filename without copying the adjacency list into memory.
+func MapDAT(filename string) (*Mapped, error) {
+	file, err := os.OpenFile(filename, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := mmap.Map(file, mmap.RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := readHeader(data)
+	if err != nil {
+		data.Unmap()
+		return nil, err
+	}
+	if h.NodeSize != NodeSize {
+		data.Unmap()
+		return nil, errors.New("graph: node size does not match build, use LoadDAT instead")
+	}
+
+	mapped := &Mapped{data: data}
+	if h.ListLen > 0 {
+		mapped.List = ((*[1 << 40]Node)(unsafe.Pointer(&data[h.ListOffset])))[:h.ListLen:h.ListLen]
+	}
+	if h.SpanLen > 0 {
+		spandata := ((*[1 << 40]uint64)(unsafe.Pointer(&data[h.SpanOffset])))[:h.SpanLen:h.SpanLen]
+		if h.SpanOffset%8 == 0 {
+			mapped.Span = spandata
+		} else {
+			// legacy files may have the span unaligned
+			mapped.Span = append([]uint64{}, spandata...)
+		}
+	}
+
+	return mapped, nil
+}
+
+func (mapped *Mapped) Close() error {
+	mapped.List, mapped.Span = nil, nil
+	return mapped.data.Unmap()
+}
@@ -0,0 +1,22 @@
+//go:build !node64
+// +build !node64
+
+package graph
+
+import (
+	"sync/atomic"
+
+	"github.com/shawnsmithdev/zermelo/zuint32"
+)
+
+// Node is a 32-bit node id, build with `-tags node64` for larger graphs.
+type Node = uint32
+
+// Index is used for positions in a frontier.
+type Index = uint32
+
+const NodeSize = 4
+
+func SortNodes(nodes, buffer []Node) { zuint32.SortBYOB(nodes, buffer) }
+
+func AddIndex(addr *Index, delta Index) Index { return atomic.AddUint32(addr, delta) }
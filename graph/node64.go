@@ -0,0 +1,22 @@
+//go:build node64
+// +build node64
+
+package graph
+
+import (
+	"sync/atomic"
+
+	"github.com/shawnsmithdev/zermelo/zuint64"
+)
+
+// Node is a 64-bit node id, used when building with `-tags node64`.
+type Node = uint64
+
+// Index is used for positions in a frontier.
+type Index = uint64
+
+const NodeSize = 8
+
+func SortNodes(nodes, buffer []Node) { zuint64.SortBYOB(nodes, buffer) }
+
+func AddIndex(addr *Index, delta Index) Index { return atomic.AddUint64(addr, delta) }
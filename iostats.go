@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+type IOStats struct {
+	MajorFaults int64
+	MinorFaults int64
+	BlockReads  int64
+}
+
+func (a IOStats) Sub(b IOStats) IOStats {
+	return IOStats{
+		MajorFaults: a.MajorFaults - b.MajorFaults,
+		MinorFaults: a.MinorFaults - b.MinorFaults,
+		BlockReads:  a.BlockReads - b.BlockReads,
+	}
+}
+
+func (a IOStats) String() string {
+	return fmt.Sprintf("major faults %d, minor faults %d, block reads %d", a.MajorFaults, a.MinorFaults, a.BlockReads)
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "syscall"
+
+func ReadIOStats() IOStats {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return IOStats{}
+	}
+	return IOStats{
+		MajorFaults: int64(usage.Majflt),
+		MinorFaults: int64(usage.Minflt),
+		BlockReads:  int64(usage.Inblock),
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+var (
+	isolate  = flag.Bool("isolate", false, "run every dataset and approach in a separate process")
+	cpus     = flag.String("cpus", "", "pin benchmarking to a CPU list such as 0-3,8 (linux only)")
+	isolated = flag.String("isolated-sources", "", "comma separated sources of an isolated process (set by -isolate)")
+	policy   = flag.String("affinity", "none", "pin worker threads: none, compact, scatter or a cpu list (linux only)")
+)
+
+// IsIsolated reports whether this process was started by -isolate.
+func IsIsolated() bool { return *isolated != "" }
+
+// PinCPUs applies -cpus to the current process,
+// when -isolate is used only the child processes are pinned.
+func PinCPUs() error {
+	if *cpus == "" || *isolate {
+		return nil
+	}
+	list, err := affinity.ParseCPUs(*cpus)
+	if err != nil {
+		return err
+	}
+	if err := affinity.SetProcess(list); err != nil {
+		return err
+	}
+	if os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(len(list))
+	}
+	return nil
+}
+
+// PinWorkers applies -affinity to the CPUs selected by -cpus and
+// starts the pinned workers for the largest goroutine count of procsList.
+func PinWorkers(procsList []int) error {
+	var topology []affinity.CPU
+	if *policy != "none" {
+		var allowed []int
+		var err error
+		if *cpus != "" {
+			if allowed, err = affinity.ParseCPUs(*cpus); err != nil {
+				return err
+			}
+		}
+		if topology, err = affinity.Topology(allowed); err != nil {
+			return err
+		}
+	}
+
+	p, err := affinity.Parse(*policy, topology)
+	if err != nil {
+		return err
+	}
+	affinity.Set(p)
+
+	workers := 0
+	for _, procs := range procsList {
+		if procs > workers {
+			workers = procs
+		}
+	}
+	if err := affinity.Start(workers); err != nil {
+		return err
+	}
+	if !IsIsolated() {
+		fmt.Fprintln(os.Stderr, "# Affinity", p)
+	}
+	return nil
+}
+
+// IsolatedSources returns the sources passed to an isolated process.
+func IsolatedSources() ([]graph.Node, error) {
+	var sources []graph.Node
+	for _, field := range strings.Split(*isolated, ",") {
+		source, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -isolated-sources %q", *isolated)
+		}
+		sources = append(sources, graph.Node(source))
+	}
+	return sources, nil
+}
+
+// Isolation runs approaches in child processes, which map the graph from a .dat file.
+type Isolation struct {
+	dir   string
+	files map[string]string
+}
+
+// NewIsolation writes datasets that aren't .dat files to a temporary directory.
+func NewIsolation(datasets []Dataset) (*Isolation, error) {
+	if *traceFile != "" || *goTraceFile != "" {
+		return nil, errors.New("-trace and -gotrace are not supported with -isolate")
+	}
+
+	dir, err := ioutil.TempDir("", "bfs-isolate")
+	if err != nil {
+		return nil, err
+	}
+
+	isolation := &Isolation{dir: dir, files: map[string]string{}}
+	for _, dataset := range datasets {
+		file := dataset.Info.File
+		if filepath.Ext(file) != ".dat" {
+			file = filepath.Join(dir, dataset.Name+".dat")
+			fmt.Fprintln(os.Stderr, "# Converting", dataset.Info.File, "to", file)
+			if err := graph.WriteDat(file, dataset.Graph); err != nil {
+				isolation.Close()
+				return nil, err
+			}
+		}
+		isolation.files[dataset.Name] = file
+	}
+	return isolation, nil
+}
+
+// Close removes the converted datasets.
+func (isolation *Isolation) Close() error {
+	return os.RemoveAll(isolation.dir)
+}
+
+// Run benchmarks a single approach in a child process, invalid is set when
+// the child failed verification or allocated with -allocfree.
+func (isolation *Isolation) Run(dataset Dataset, approach string, procs int) (ms measure.Measurements, invalid bool, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var sources []string
+	for _, source := range dataset.Sources {
+		sources = append(sources, strconv.Itoa(int(source)))
+	}
+
+	args := []string{
+		"-isolated-sources", strings.Join(sources, ","),
+		"-checksums", dataset.Info.Checksum,
+		"-format", "json",
+		"-mmap",
+		"-manifest=",
+		"-run", "^" + regexp.QuoteMeta(approach) + "$",
+		"-N", strconv.Itoa(*N),
+		"-cold=" + strconv.FormatBool(*cold),
+		"-verify=" + strconv.FormatBool(*verify),
+		"-perf=" + strconv.FormatBool(*perf),
+		"-allocfree=" + strconv.FormatBool(*allocFree),
+		"-cpus", *cpus,
+		"-affinity", *policy,
+	}
+	if procs > 0 {
+		args = append(args, "-procs", strconv.Itoa(procs))
+	}
+	args = append(args, isolation.files[dataset.Name])
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() != 1 || stdout.Len() == 0 {
+			return nil, false, fmt.Errorf("%v on %v: %w", approach, dataset.Name, err)
+		}
+		invalid = true
+	}
+
+	result, err := measure.Parse(&stdout)
+	if err != nil {
+		return nil, invalid, fmt.Errorf("%v on %v: %w", approach, dataset.Name, err)
+	}
+	for i := range result.Measurements {
+		result.Measurements[i].Dataset = dataset.Name
+	}
+	return result.Measurements, invalid, nil
+}
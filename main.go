@@ -3,64 +3,57 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math"
 	"os"
-	"path/filepath"
-	"reflect"
-	"regexp"
 	"runtime"
 	"runtime/debug"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+	"github.com/egonelbre/a-tale-of-bfs/variants"
 	"github.com/egonelbre/exp/qpc"
-	"github.com/gonum/stat"
-	"gonum.org/v1/gonum/floats"
-
-	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
-	s01_reuse_level "github.com/egonelbre/a-tale-of-bfs/01_reuse_level"
-	s02_sort "github.com/egonelbre/a-tale-of-bfs/02_sort"
-	s03_inline_sort "github.com/egonelbre/a-tale-of-bfs/03_inline_sort"
-	s04_radix_sort "github.com/egonelbre/a-tale-of-bfs/04_radix_sort"
-	s05_lift_level "github.com/egonelbre/a-tale-of-bfs/05_lift_level"
-
-	s06_ordering "github.com/egonelbre/a-tale-of-bfs/06_ordering"
-	s07_fused "github.com/egonelbre/a-tale-of-bfs/07_fused"
-	s07_fused_if "github.com/egonelbre/a-tale-of-bfs/07_fused_if"
-	s08_cuckoo "github.com/egonelbre/a-tale-of-bfs/08_cuckoo"
-
-	s09_unroll_4 "github.com/egonelbre/a-tale-of-bfs/09_unroll_4"
-	s09_unroll_8 "github.com/egonelbre/a-tale-of-bfs/09_unroll_8"
-	s09_unroll_8_4 "github.com/egonelbre/a-tale-of-bfs/09_unroll_8_4"
-
-	s10_parallel "github.com/egonelbre/a-tale-of-bfs/10_parallel"
-	s10_parchan "github.com/egonelbre/a-tale-of-bfs/10_parchan"
-	s11_frontier "github.com/egonelbre/a-tale-of-bfs/11_frontier"
-	s12_almost "github.com/egonelbre/a-tale-of-bfs/12_almost"
-	s13_marking "github.com/egonelbre/a-tale-of-bfs/13_marking"
-
-	s14_early_2 "github.com/egonelbre/a-tale-of-bfs/14_early_2"
-	s14_early_3 "github.com/egonelbre/a-tale-of-bfs/14_early_3"
-	s14_early_4 "github.com/egonelbre/a-tale-of-bfs/14_early_4"
-	s14_early_r "github.com/egonelbre/a-tale-of-bfs/14_early_r"
-
-	s15_worker "github.com/egonelbre/a-tale-of-bfs/15_worker"
-	s16_busy "github.com/egonelbre/a-tale-of-bfs/16_busy"
 )
 
 var (
-	cold = flag.Bool("cold", false, "also include cold run")
-	run  = flag.String("run", "", "filter approaches")
-	N    = flag.Int("N", 10, "benchmark iterations")
+	cold    = flag.Bool("cold", false, "also include cold run")
+	run     = flag.String("run", "", "select approaches, e.g. \"@parallel&procs=8,-cuckoo\", see -list")
+	list    = flag.Bool("list", false, "list approaches selected by -run and exit")
+	N       = flag.Int("N", 10, "benchmark iterations")
+	mmapped = flag.Bool("mmap", false, "map .dat files instead of loading them and report I/O")
+
+	source  = flag.Int("source", 2, "source node")
+	sources = flag.Int("sources", 0, "pick N random non-isolated source nodes instead of -source")
+	seed    = flag.Int64("seed", 1, "seed for picking sources")
+
+	verify = flag.Bool("verify", true, "verify levels node-by-node against baseline")
+	format = flag.String("format", "tsv", "output format: tsv or json")
+	perf   = flag.Bool("perf", false, "record hardware performance counters (linux only)")
+
+	allocFree = flag.Bool("allocfree", false, "fail approaches that allocate beyond setting up a search after a warm-up run")
+	history   = flag.String("history", "", "append results to a JSON lines history file")
+	procs     = flag.String("procs", "4,max", "goroutine counts for parallel approaches, \"sweep\" for powers of two up to GOMAXPROCS")
 )
 
-type IterateFn func(g *graph.Graph, source graph.Node, levels []int)
-type IterateFnParallel func(g *graph.Graph, source graph.Node, levels []int, procs int)
+type IterateFn = variants.Iterate
 
-func IterateParallel(procs int, iterate IterateFnParallel) IterateFn {
-	return func(g *graph.Graph, source graph.Node, levels []int) {
-		iterate(g, source, levels, procs)
+// ListVariants prints all approaches with their tags,
+// approaches selected by -run are marked with *.
+func ListVariants(selection *variants.Selection, procsList []int) {
+	for _, v := range variants.All {
+		procs := procsList
+		if v.Parallel == nil {
+			procs = []int{0}
+		}
+		for _, p := range procs {
+			mark := " "
+			if selection.Match(v, p) {
+				mark = "*"
+			}
+			fmt.Printf("%v %v\t%v\n", mark, v.Label(p), strings.Join(v.Tags, ","))
+		}
 	}
 }
 
@@ -75,207 +68,347 @@ func EmptyRun(g *graph.Graph, source graph.Node, iterate IterateFn) {
 	runtime.GC()
 }
 
-func Benchmark(g *graph.Graph, source graph.Node, iterate IterateFn, N int) []float64 {
-	timings := []float64{}
+// Benchmark runs iterate N times, counters are only recorded when perf is not nil.
+// SetupAllocs counts the allocations of a search that ends at its source, which
+// are the allocations every call makes for the visited set, frontiers and workers.
+func SetupAllocs(iterate IterateFn) float64 {
+	// the graph has no edges and is large enough to keep the setup off the stack
+	g := graph.FromEdges(1<<16, nil)
+	levels := make([]int, g.Order())
+	iterate(g, 0, levels)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	iterate(g, 0, levels)
+	runtime.ReadMemStats(&after)
+	return float64(after.Mallocs - before.Mallocs)
+}
+
+// ExtraAllocs returns the most allocations of a run beyond the setup allocations.
+func ExtraAllocs(runs []measure.Memory, setup float64) float64 {
+	extra := 0.0
+	for _, run := range runs {
+		extra = math.Max(extra, run.Allocs-setup)
+	}
+	return extra
+}
+
+func Benchmark(g *graph.Graph, source graph.Node, iterate IterateFn, N int, perf *Perf) (timings []float64, counters []measure.Counters, memory []measure.Memory, levels []int) {
+	timings = []float64{}
 	for k := 0; k < N; k++ {
 		var start, stop qpc.Count
-		levels := make([]int, g.Order())
+		var before, after runtime.MemStats
+		levels = make([]int, g.Order())
 		{
 			debug.SetGCPercent(0)
 			runtime.GC()
+			if perf != nil {
+				perf.Open()
+			}
+			// ReadMemStats stops the world, so it's kept outside of the counters
+			runtime.ReadMemStats(&before)
+			if perf != nil {
+				perf.Start()
+			}
 			{
 				start = qpc.Now()
 				iterate(g, source, levels)
 				stop = qpc.Now()
 			}
+			if perf != nil {
+				perf.Stop()
+			}
+			runtime.ReadMemStats(&after)
+			if perf != nil {
+				counters = append(counters, perf.Read())
+			}
 			debug.SetGCPercent(100)
 			runtime.GC()
 		}
 		timings = append(timings, stop.Sub(start).Duration().Seconds())
+
+		allocated := float64(after.TotalAlloc - before.TotalAlloc)
+		memory = append(memory, measure.Memory{
+			PeakHeap:  float64(before.HeapAlloc) + allocated,
+			Allocated: allocated,
+			Allocs:    float64(after.Mallocs - before.Mallocs),
+		})
 	}
 
-	return timings
+	return timings, counters, memory, levels
 }
 
-func Test(g *graph.Graph, name string, source graph.Node, iterate IterateFn, expected []int) {
-	levels := make([]int, g.Order())
-
-	done := make(chan struct{})
-	go func() {
-		iterate(g, source, levels)
-		done <- struct{}{}
-	}()
-
-	select {
-	case <-done:
-	case <-time.After(time.Second):
-		fmt.Fprintln(os.Stderr, "Locked ", name)
-		return
-	}
+func Stats(timings []float64) string {
+	var m measure.Measurement
+	m.Summarize(timings)
+	return fmt.Sprintf("%.2f\t%.2f\t%.2f\t%.2f\t%.2f", m.Median, m.Average, m.Stdev, m.Min, m.Max)
+}
 
-	levelCounts := []int{}
-	for _, level := range levels {
-		if level >= len(levelCounts) {
-			levelCounts = append(levelCounts, make([]int, level-len(levelCounts)+1)...)
+// ParseProcs parses a comma separated list of goroutine counts,
+// where "max" is replaced with GOMAXPROCS.
+func ParseProcs(list string, max int) ([]int, error) {
+	if list == "sweep" {
+		var procs []int
+		for n := 1; n < max; n *= 2 {
+			procs = append(procs, n)
 		}
-		levelCounts[level]++
+		return append(procs, max), nil
 	}
 
-	if !reflect.DeepEqual(levelCounts, expected) {
-		fmt.Fprintln(os.Stderr, "Invalid ", name, "got", levelCounts, "exp", expected)
+	var procs []int
+	seen := map[int]bool{}
+	for _, field := range strings.Split(list, ",") {
+		n := max
+		if field != "max" {
+			var err error
+			n, err = strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid procs %q", field)
+			}
+		}
+		if !seen[n] {
+			seen[n] = true
+			procs = append(procs, n)
+		}
 	}
+	return procs, nil
 }
 
-func Stats(timings []float64) string {
-	sort.Float64s(timings)
-
-	min := floats.Min(timings)
-	max := floats.Max(timings)
-	mean, variance := stat.MeanStdDev(timings, nil)
-	q := stat.Quantile(0.5, stat.Empirical, timings, nil)
-
-	ms := func(v float64) string {
-		return fmt.Sprintf("%.2f", v*1000)
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			os.Exit(Compare(os.Args[2:]))
+		case "trend":
+			os.Exit(Trend(os.Stdout, os.Args[2:]))
+		case "manifest":
+			os.Exit(WriteManifest(os.Args[2:]))
+		}
 	}
 
-	return fmt.Sprintf("%v\t%v\t%v\t%v\t%v", ms(q), ms(mean), ms(variance), ms(min), ms(max))
-}
-
-func main() {
 	runtime.LockOSThread()
 	flag.Parse()
 
-	type Dataset struct {
-		Name  string
-		Graph *graph.Graph
+	if err := PinCPUs(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-
-	var datasets []Dataset
-	for _, filename := range flag.Args() {
-		fmt.Fprintln(os.Stderr, "# Loading dataset ", filename)
-		var g *graph.Graph
-		var err error
-		switch filepath.Ext(filename) {
-		case ".dat":
-			g, err = graph.LoadDAT(filename)
-		case ".txt":
-			g, err = graph.LoadText(filename)
-		default:
-			fmt.Fprintln(os.Stderr, "unknown file format: "+filename)
-			os.Exit(1)
-		}
-
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-
-		datasets = append(datasets, Dataset{
-			Name:  removeExt(filepath.Base(filename)),
-			Graph: g,
-		})
+	procsList, err := ParseProcs(*procs, runtime.GOMAXPROCS(-1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := PinWorkers(procsList); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	selection, err := variants.ParseSelection(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *list {
+		ListVariants(selection, procsList)
+		return
 	}
 
-	const SOURCE = graph.Node(2)
-
-	g10k, err := graph.LoadText("data/sg-10k-250k.txt")
+	datasets, closeDatasets, err := LoadDatasets(flag.Args())
+	defer closeDatasets()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	max := runtime.GOMAXPROCS(-1)
-	maxs := fmt.Sprintf("%dx", max)
+	if *ranks > 0 {
+		if err := RunRanks(datasets); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	iterators := []struct {
+	type Iterator struct {
 		Name    string
 		Iterate IterateFn
 		Skip    bool
-	}{
-		{"baseline", s00_baseline.BreadthFirst, false},
-		{"reuse level", s01_reuse_level.BreadthFirst, false},
-		{"sort", s02_sort.BreadthFirst, false},
-		{"inline sort", s03_inline_sort.BreadthFirst, false},
-		{"radix sort", s04_radix_sort.BreadthFirst, false},
-		{"lift level", s05_lift_level.BreadthFirst, false},
-
-		{"ordering", s06_ordering.BreadthFirst, false},
-		{"fused", s07_fused.BreadthFirst, false},
-		{"fused if", s07_fused_if.BreadthFirst, false},
-		{"cuckoo", s08_cuckoo.BreadthFirst, true},
-
-		{"unroll 4", s09_unroll_4.BreadthFirst, false},
-		{"unroll 8", s09_unroll_8.BreadthFirst, false},
-		{"unroll 8 4", s09_unroll_8_4.BreadthFirst, false},
-
-		{"parallel", s10_parallel.BreadthFirst, true},
-		{"parchan 4x", IterateParallel(4, s10_parchan.BreadthFirst), false},
-		{"parchan " + maxs, IterateParallel(max, s10_parchan.BreadthFirst), false},
-
-		{"frontier 4x", IterateParallel(4, s11_frontier.BreadthFirst), false},
-		{"frontier " + maxs, IterateParallel(max, s11_frontier.BreadthFirst), false},
-
-		{"almost 4x", IterateParallel(4, s12_almost.BreadthFirst), false},
-		{"almost " + maxs, IterateParallel(max, s12_almost.BreadthFirst), false},
+		Procs   int
+	}
 
-		{"marking 4x", IterateParallel(4, s13_marking.BreadthFirst), false},
-		{"marking " + maxs, IterateParallel(max, s13_marking.BreadthFirst), false},
+	var iterators []Iterator
+	for _, v := range variants.All {
+		procs := procsList
+		if v.Parallel == nil {
+			procs = []int{0}
+		}
+		for _, p := range procs {
+			if selection.Match(v, p) {
+				iterators = append(iterators, Iterator{v.Label(p), v.WithProcs(p), v.Has(variants.Skip), p})
+			}
+		}
+	}
 
-		{"early2 4x", IterateParallel(4, s14_early_2.BreadthFirst), false},
-		{"early2 " + maxs, IterateParallel(max, s14_early_2.BreadthFirst), false},
+	stopTracing, err := StartTracing()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-		{"early3 4x", IterateParallel(4, s14_early_3.BreadthFirst), false},
-		{"early3 " + maxs, IterateParallel(max, s14_early_3.BreadthFirst), false},
+	var counters *Perf
+	if *perf {
+		counters, err = OpenPerf()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "# hardware counters disabled:", err)
+		} else if unavailable := counters.Unavailable(); len(unavailable) > 0 {
+			fmt.Fprintln(os.Stderr, "# hardware counters unavailable:", strings.Join(unavailable, ", "))
+		}
+	}
 
-		{"early4 4x", IterateParallel(4, s14_early_4.BreadthFirst), false},
-		{"early4 " + maxs, IterateParallel(max, s14_early_4.BreadthFirst), false},
+	invalid := false
+	// isolated processes are verified by the parent
+	if *verify && !IsIsolated() {
+		g, source, close, err := LoadVerifyGraph()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer close()
 
-		{"earlyR 4x", IterateParallel(4, s14_early_r.BreadthFirst), false},
-		{"earlyR " + maxs, IterateParallel(max, s14_early_r.BreadthFirst), false},
+		oracle := NewOracle(g)
+		for _, it := range iterators {
+			if err := oracle.Run(source, it.Iterate, time.Second); err != nil {
+				fmt.Fprintln(os.Stderr, "Invalid ", it.Name, err)
+				invalid = true
+			}
+		}
+	}
 
-		{"worker 4x", IterateParallel(4, s15_worker.BreadthFirst), false},
-		{"worker " + maxs, IterateParallel(max, s15_worker.BreadthFirst), false},
+	//w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	// defer w.Flush()
 
-		{"busy 4x", IterateParallel(4, s16_busy.BreadthFirst), false},
-		{"busy " + maxs, IterateParallel(max, s16_busy.BreadthFirst), false},
+	out, err := NewOutput(os.Stdout, datasets)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	for _, it := range iterators {
-		Test(g10k, it.Name, SOURCE, it.Iterate, []int{490000, 1, 55, 2416, 7528})
+	var isolation *Isolation
+	if *isolate {
+		isolation, err = NewIsolation(datasets)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer isolation.Close()
 	}
 
-	rx := regexp.MustCompile(*run)
-
-	//w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	// defer w.Flush()
-
-	w := os.Stdout
-	fmt.Fprintf(w, "dataset\tapproach\tmed\tavg\tvar\tmin\tmax\n")
 	for _, dataset := range datasets {
 		fmt.Fprintln(os.Stderr, "# Dataset", dataset.Name)
+		oracle := NewOracle(dataset.Graph)
 		for _, it := range iterators {
-			if *run != "" && !rx.MatchString(it.Name) {
+			if isolation != nil {
+				ms, failed, err := isolation.Run(dataset, it.Name, it.Procs)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, m := range ms {
+					out.Add(m)
+				}
+				invalid = invalid || failed
 				continue
 			}
 
 			fmt.Fprint(os.Stderr, "  > ", it.Name, "\t")
 
-			if *cold {
-				EmptyRun(dataset.Graph, SOURCE, it.Iterate)
-			}
-
 			n := *N
 			if it.Skip {
 				n = 1
 			}
 
-			timings := Benchmark(dataset.Graph, SOURCE, it.Iterate, n)
-			stats := Stats(timings)
-			fmt.Fprintln(os.Stderr, stats)
-			fmt.Fprintf(w, "%v\t%v\t%v\n", dataset.Name, it.Name, stats)
+			var all []float64
+			var allCounters []measure.Counters
+			var allMemory []measure.Memory
+			var perSource []string
+			var throughput Throughput
+
+			setup := 0.0
+			if *allocFree {
+				setup = SetupAllocs(it.Iterate)
+			}
+
+			before := ReadIOStats()
+			ResetPeakRSS()
+			for _, source := range dataset.Sources {
+				if *cold || *allocFree {
+					EmptyRun(dataset.Graph, source, it.Iterate)
+				}
+
+				timings, iterationCounters, memory, levels := Benchmark(dataset.Graph, source, it.Iterate, n, counters)
+				name := fmt.Sprintf("%v/%v/%v", dataset.Name, it.Name, source)
+				if err := TraceRun(name, dataset.Graph, source, it.Iterate); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				traversal := Traversed(dataset.Graph, levels)
+				if *verify {
+					if err := oracle.Check(source, levels); err != nil {
+						fmt.Fprintln(os.Stderr, "\nInvalid ", it.Name, "from", source, err)
+						invalid = true
+					}
+				}
+
+				all = append(all, timings...)
+				allCounters = append(allCounters, iterationCounters...)
+				allMemory = append(allMemory, memory...)
+				throughput.Add(traversal, timings)
+
+				var single Throughput
+				single.Add(traversal, timings)
+				perSource = append(perSource, fmt.Sprintf("    source %v\t%v\t%v",
+					source, Stats(timings), FormatTEPS(single.TEPS())))
+			}
+			io := ReadIOStats().Sub(before)
+
+			stats := Stats(all)
+			fmt.Fprintf(os.Stderr, "%v\t%v\n", stats, throughput.String())
+			if len(perSource) > 1 {
+				for _, line := range perSource {
+					fmt.Fprintln(os.Stderr, line)
+				}
+			}
+			if *mmapped {
+				fmt.Fprintln(os.Stderr, "    io:", io)
+			}
+
+			m := Measure(dataset, it.Name, it.Procs, all, &throughput)
+			m.Memory = measure.SummarizeMemory(allMemory)
+			m.Memory.RSS = PeakRSS()
+			fmt.Fprintln(os.Stderr, "    memory:", m.Memory)
+			if *allocFree {
+				if extra := ExtraAllocs(allMemory, setup); extra > 0 {
+					fmt.Fprintf(os.Stderr, "Allocates %v: %.0f allocations in a run after warm-up beyond the %.0f of setting up a search\n", it.Name, extra, setup)
+					invalid = true
+				}
+			}
+			if counters != nil {
+				m.Counters = measure.Average(allCounters)
+				m.IterationCounters = allCounters
+				fmt.Fprintln(os.Stderr, "    perf:", m.Counters)
+			}
+			out.Add(m)
 		}
 	}
 	fmt.Fprint(os.Stderr, "\n")
+	stopTracing()
+
+	if err := out.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if invalid {
+		os.Exit(1)
+	}
 }
 
 func removeExt(name string) string {
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/variants"
+)
+
+func TestAllocFree(t *testing.T) {
+	g, err := graph.LoadText(verifyFile)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		approach string
+		free     bool
+	}{
+		{"baseline", true},
+		{"ordering", true},
+		// sort.Slice allocates in every level
+		{"sort", false},
+	}
+	for _, test := range tests {
+		v, ok := variants.Lookup(test.approach)
+		if !ok {
+			t.Fatalf("%v not registered", test.approach)
+		}
+		iterate := v.Iterate
+
+		EmptyRun(g, verifySource, iterate)
+		_, _, memory, _ := Benchmark(g, verifySource, iterate, 3, nil)
+		setup := SetupAllocs(iterate)
+		if setup == 0 {
+			t.Errorf("%v: setup doesn't allocate", test.approach)
+		}
+
+		extra := ExtraAllocs(memory, setup)
+		if free := extra == 0; free != test.free {
+			t.Errorf("%v: %v allocations beyond the %v of setup", test.approach, extra, setup)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package measure
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Comparison describes the change between two measurements of the same entry.
+type Comparison struct {
+	Entry
+	Old, New Measurement
+
+	// Speedup is the ratio of old and new median, above 1 is faster.
+	Speedup float64
+	// Low and High are the confidence interval bounds for Speedup,
+	// equal to Speedup when timings are not available.
+	Low, High float64
+	// P is the Mann-Whitney U test p-value, 1 when timings are not available.
+	P float64
+}
+
+// Compare computes the speedup from old to new, confidence is
+// the confidence interval size used for bootstrapping, e.g. 0.95.
+func Compare(old, new Measurement, confidence float64) Comparison {
+	c := Comparison{
+		Entry: old.Entry,
+		Old:   old,
+		New:   new,
+		P:     1,
+	}
+	c.Speedup = old.Median / new.Median
+	c.Low, c.High = c.Speedup, c.Speedup
+
+	if len(old.Timings) > 0 && len(new.Timings) > 0 {
+		c.P = MannWhitney(old.Timings, new.Timings)
+		c.Low, c.High = BootstrapSpeedup(old.Timings, new.Timings, 10000, confidence)
+	}
+	return c
+}
+
+// Indistinguishable reports whether the difference between a and b is
+// within noise: the Mann-Whitney p-value is at least alpha when both have
+// timings, otherwise the median ± stdev ranges overlap.
+func Indistinguishable(a, b Measurement, alpha float64) bool {
+	if len(a.Timings) > 1 && len(b.Timings) > 1 {
+		return MannWhitney(a.Timings, b.Timings) >= alpha
+	}
+	return a.Median-a.Stdev <= b.Median+b.Stdev && b.Median-b.Stdev <= a.Median+a.Stdev
+}
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test,
+// using the normal approximation with tie correction.
+func MannWhitney(a, b []float64) float64 {
+	type sample struct {
+		value float64
+		first bool
+	}
+
+	all := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range b {
+		all = append(all, sample{v, false})
+	}
+	sort.Slice(all, func(i, k int) bool { return all[i].value < all[k].value })
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+
+	var rankSum, ties float64
+	for i := 0; i < len(all); {
+		k := i
+		for k < len(all) && all[k].value == all[i].value {
+			k++
+		}
+		rank := float64(i+k+1) / 2
+		for _, s := range all[i:k] {
+			if s.first {
+				rankSum += rank
+			}
+		}
+		t := float64(k - i)
+		ties += t*t*t - t
+		i = k
+	}
+
+	u := rankSum - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / sigma
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// BootstrapSpeedup estimates the confidence interval of the median speedup
+// by resampling the timings.
+func BootstrapSpeedup(old, new []float64, iterations int, confidence float64) (low, high float64) {
+	rng := rand.New(rand.NewSource(1))
+
+	resample := func(xs, buf []float64) float64 {
+		for i := range buf {
+			buf[i] = xs[rng.Intn(len(xs))]
+		}
+		return median(buf)
+	}
+
+	oldbuf := make([]float64, len(old))
+	newbuf := make([]float64, len(new))
+	speedups := make([]float64, iterations)
+	for i := range speedups {
+		speedups[i] = resample(old, oldbuf) / resample(new, newbuf)
+	}
+	sort.Float64s(speedups)
+
+	tail := (1 - confidence) / 2
+	return speedups[int(tail*float64(iterations-1))], speedups[int((1-tail)*float64(iterations-1))]
+}
+
+func median(xs []float64) float64 {
+	sort.Float64s(xs)
+	n := len(xs)
+	if n%2 == 1 {
+		return xs[n/2]
+	}
+	return (xs[n/2-1] + xs[n/2]) / 2
+}
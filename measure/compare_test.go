@@ -0,0 +1,67 @@
+package measure
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitney(t *testing.T) {
+	same := MannWhitney([]float64{1, 2, 3, 4, 5}, []float64{1, 2, 3, 4, 5})
+	if same < 0.9 {
+		t.Errorf("identical samples: p = %v", same)
+	}
+
+	// U = 3, mean = 32, sigma = sqrt(64*17/12), z = (29 - 0.5) / sigma
+	a := []float64{1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7, 1.8}
+	b := []float64{1.65, 1.75, 1.85, 1.9, 2.0, 2.1, 2.2, 2.3}
+	p := MannWhitney(a, b)
+	if math.Abs(p-0.00276) > 1e-5 {
+		t.Errorf("got p = %v, expected 0.00276", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := Measurement{Median: 2, Timings: []float64{2, 2.1, 1.9, 2.05, 1.95, 2}}
+	new := Measurement{Median: 1, Timings: []float64{1, 1.05, 0.95, 1.02, 0.98, 1}}
+
+	c := Compare(old, new, 0.95)
+	if c.Speedup != 2 {
+		t.Errorf("speedup %v", c.Speedup)
+	}
+	if !(c.Low <= 2 && 2 <= c.High && c.Low > 1.7 && c.High < 2.3) {
+		t.Errorf("interval [%v, %v]", c.Low, c.High)
+	}
+	if c.P > 0.01 {
+		t.Errorf("p = %v", c.P)
+	}
+
+	summary := Compare(Measurement{Median: 2}, Measurement{Median: 4}, 0.95)
+	if summary.Speedup != 0.5 || summary.Low != 0.5 || summary.High != 0.5 || summary.P != 1 {
+		t.Errorf("got %+v", summary)
+	}
+}
+
+func TestIndistinguishable(t *testing.T) {
+	tests := []struct {
+		a, b Measurement
+		want bool
+	}{
+		{Measurement{Median: 1, Stdev: 0.1}, Measurement{Median: 1.15, Stdev: 0.1}, true},
+		{Measurement{Median: 1, Stdev: 0.1}, Measurement{Median: 1.3, Stdev: 0.1}, false},
+		{
+			Measurement{Timings: []float64{1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7, 1.8}},
+			Measurement{Timings: []float64{1.65, 1.75, 1.85, 1.9, 2.0, 2.1, 2.2, 2.3}},
+			false,
+		},
+		{
+			Measurement{Timings: []float64{1, 2, 3, 4, 5}},
+			Measurement{Timings: []float64{1.5, 2.5, 3.5, 4.5}},
+			true,
+		},
+	}
+	for i, test := range tests {
+		if got := Indistinguishable(test.a, test.b, 0.05); got != test.want {
+			t.Errorf("%d: got %v, expected %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package measure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Environment describes the machine and configuration of a benchmark run.
+type Environment struct {
+	Host       string   `json:"host"`
+	OS         string   `json:"os"`
+	Arch       string   `json:"arch"`
+	CPU        string   `json:"cpu"`
+	Caches     []string `json:"caches,omitempty"`
+	NumCPU     int      `json:"numcpu"`
+	GOMAXPROCS int      `json:"gomaxprocs"`
+	// Affinity is the policy pinning worker threads to CPUs.
+	Affinity  string            `json:"affinity,omitempty"`
+	GoVersion string            `json:"go"`
+	Commit    string            `json:"commit,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Flags     map[string]string `json:"flags,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// ReadEnvironment describes the current process,
+// flags are read from flag.CommandLine.
+func ReadEnvironment() Environment {
+	env := Environment{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPU:        cpuModel(),
+		Caches:     cpuCaches(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(-1),
+		GoVersion:  runtime.Version(),
+		Commit:     commit(),
+		Args:       flag.Args(),
+		Flags:      map[string]string{},
+		Time:       time.Now().UTC().Truncate(time.Second),
+	}
+	env.Host, _ = os.Hostname()
+	flag.VisitAll(func(f *flag.Flag) {
+		env.Flags[f.Name] = f.Value.String()
+	})
+	return env
+}
+
+func commit() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		revision, modified := "", false
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if revision != "" {
+			if modified {
+				revision += "-dirty"
+			}
+			return revision
+		}
+	}
+
+	out, err := exec.Command("git", "describe", "--always", "--abbrev=40", "--dirty").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Dataset describes the graph used for measurements.
+type Dataset struct {
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Checksum string `json:"sha256"`
+	Nodes    int    `json:"nodes"`
+	Edges    int    `json:"edges"`
+}
+
+// Checksum returns the sha256 of a file.
+func Checksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
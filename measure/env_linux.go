@@ -0,0 +1,51 @@
+package measure
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value := splitKeyValue(scanner.Text(), ":")
+		if key == "model name" {
+			return value
+		}
+	}
+	return ""
+}
+
+func cpuCaches() []string {
+	dirs, _ := filepath.Glob("/sys/devices/system/cpu/cpu0/cache/index*")
+
+	var caches []string
+	for _, dir := range dirs {
+		read := func(name string) string {
+			data, _ := ioutil.ReadFile(filepath.Join(dir, name))
+			return strings.TrimSpace(string(data))
+		}
+
+		level, kind, size := read("level"), read("type"), read("size")
+		if level == "" || size == "" {
+			continue
+		}
+		switch kind {
+		case "Data":
+			level += "d"
+		case "Instruction":
+			level += "i"
+		}
+		caches = append(caches, "L"+level+" "+size)
+	}
+	return caches
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package measure
+
+func cpuModel() string { return "" }
+
+func cpuCaches() []string { return nil }
@@ -0,0 +1,92 @@
+package measure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// AppendHistory appends result as a single JSON line to filename,
+// per iteration data is dropped to keep the history compact.
+func AppendHistory(filename string, result *Result) error {
+	compact := *result
+	compact.Measurements = make(Measurements, len(result.Measurements))
+	for i, m := range result.Measurements {
+		m.Timings, m.IterationCounters = nil, nil
+		compact.Measurements[i] = m
+	}
+
+	data, err := json.Marshal(compact)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadHistory reads results in the order they were appended.
+func ReadHistory(filename string) ([]Result, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var history []Result
+	dec := json.NewDecoder(f)
+	for {
+		var result Result
+		if err := dec.Decode(&result); err == io.EOF {
+			return history, nil
+		} else if err != nil {
+			return history, fmt.Errorf("%v: entry %d: %w", filename, len(history)+1, err)
+		}
+		history = append(history, result)
+	}
+}
+
+// ChangePoints finds indices where the level of xs shifts, using binary
+// segmentation: each segment is split where the Mann-Whitney p-value between
+// the sides is smallest, the split is kept when the p-value is below alpha and
+// the medians differ by more than threshold. Each segment has at least min values.
+func ChangePoints(xs []float64, threshold, alpha float64, min int) []int {
+	if min < 1 {
+		min = 1
+	}
+
+	var points []int
+	var split func(lo, hi int)
+	split = func(lo, hi int) {
+		best, bestP := -1, alpha
+		for k := lo + min; k <= hi-min; k++ {
+			if p := MannWhitney(xs[lo:k], xs[k:hi]); p < bestP {
+				best, bestP = k, p
+			}
+		}
+		if best < 0 {
+			return
+		}
+		left, right := median(clone(xs[lo:best])), median(clone(xs[best:hi]))
+		// the relative change from a zero median is infinite
+		if left == right || (left != 0 && math.Abs(right-left)/math.Abs(left) <= threshold) {
+			return
+		}
+		split(lo, best)
+		points = append(points, best)
+		split(best, hi)
+	}
+	split(0, len(xs))
+	return points
+}
+
+func clone(xs []float64) []float64 { return append([]float64(nil), xs...) }
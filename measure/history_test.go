@@ -0,0 +1,67 @@
+package measure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "history.jsonl")
+
+	for _, commit := range []string{"a", "b"} {
+		result := &Result{
+			Environment: Environment{Host: "host", Commit: commit},
+			Measurements: Measurements{{
+				Entry:   Entry{"sg", "baseline"},
+				Median:  1,
+				Timings: []float64{1, 1},
+			}},
+		}
+		if err := AppendHistory(filename, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := ReadHistory(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d entries", len(history))
+	}
+	if history[0].Environment.Commit != "a" || history[1].Environment.Commit != "b" {
+		t.Errorf("wrong order: %v, %v", history[0].Environment.Commit, history[1].Environment.Commit)
+	}
+	if m := history[1].Measurements[0]; m.Median != 1 || m.Timings != nil {
+		t.Errorf("got %+v", m)
+	}
+}
+
+func TestChangePoints(t *testing.T) {
+	tests := []struct {
+		xs   []float64
+		want []int
+	}{
+		{[]float64{1, 1.01, 0.99, 1, 1.02, 0.98, 1, 1.01}, nil},
+		{[]float64{1, 1.01, 0.99, 1, 1.02, 1.5, 1.51, 1.49, 1.5, 1.52}, []int{5}},
+		{[]float64{2, 2.1, 1.9, 2, 2.05, 1, 1.05, 0.95, 1, 1.02, 1.5, 1.52, 1.48, 1.5, 1.51}, []int{5, 10}},
+		// shift smaller than the threshold
+		{[]float64{1, 1, 1, 1, 1, 1.02, 1.02, 1.02, 1.02, 1.02}, nil},
+		// shift from a zero median
+		{[]float64{0, 0, 0, 0, 2, 2, 2, 2}, []int{4}},
+	}
+	for i, test := range tests {
+		got := ChangePoints(test.xs, 0.05, 0.05, 3)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: got %v, expected %v", i, got, test.want)
+		}
+	}
+}
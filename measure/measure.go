@@ -0,0 +1,197 @@
+// Package measure contains benchmark results and their parsing.
+package measure
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gonum/stat"
+	"gonum.org/v1/gonum/floats"
+)
+
+type Entry struct {
+	Dataset  string `json:"dataset"`
+	Approach string `json:"approach"`
+}
+
+// Measurement contains timings of a single approach on a dataset,
+// all durations are in milliseconds.
+type Measurement struct {
+	Entry
+	Median  float64 `json:"med"`
+	Average float64 `json:"avg"`
+	Stdev   float64 `json:"stdev"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+
+	// throughput, zero for results without them,
+	// procs is zero for sequential approaches
+	Sources        int     `json:"sources,omitempty"`
+	Procs          int     `json:"procs,omitempty"`
+	Edges          float64 `json:"edges,omitempty"`
+	Nodes          float64 `json:"nodes,omitempty"`
+	MTEPS          float64 `json:"mteps,omitempty"`
+	MNPS           float64 `json:"mnps,omitempty"`
+	AdjacencyBytes float64 `json:"adjbytes,omitempty"`
+
+	// Counters is the average of hardware counters per iteration,
+	// zero when they were not measured
+	Counters Counters `json:"counters"`
+	Memory   Memory   `json:"memory"`
+
+	// Timings and IterationCounters contain every iteration, only available in JSON
+	Timings           []float64  `json:"timings,omitempty"`
+	IterationCounters []Counters `json:"iteration_counters,omitempty"`
+}
+
+// Counters are hardware performance counters.
+type Counters struct {
+	Cycles       float64 `json:"cycles"`
+	Instructions float64 `json:"instructions"`
+	LLCMisses    float64 `json:"llcmisses"`
+	BranchMisses float64 `json:"branchmisses"`
+	DTLBMisses   float64 `json:"dtlbmisses"`
+}
+
+// Average returns the mean of all counters.
+func Average(all []Counters) Counters {
+	var avg Counters
+	if len(all) == 0 {
+		return avg
+	}
+	for _, c := range all {
+		avg.Cycles += c.Cycles
+		avg.Instructions += c.Instructions
+		avg.LLCMisses += c.LLCMisses
+		avg.BranchMisses += c.BranchMisses
+		avg.DTLBMisses += c.DTLBMisses
+	}
+	n := float64(len(all))
+	avg.Cycles /= n
+	avg.Instructions /= n
+	avg.LLCMisses /= n
+	avg.BranchMisses /= n
+	avg.DTLBMisses /= n
+	return avg
+}
+
+func (c Counters) String() string {
+	ipc := 0.0
+	if c.Cycles > 0 {
+		ipc = c.Instructions / c.Cycles
+	}
+	return fmt.Sprintf("%.3gM cycles\t%.2f IPC\t%.3gM LLC misses\t%.3gM branch misses\t%.3gM dTLB misses",
+		c.Cycles/1e6, ipc, c.LLCMisses/1e6, c.BranchMisses/1e6, c.DTLBMisses/1e6)
+}
+
+// Memory is the memory usage of an approach in bytes.
+type Memory struct {
+	// PeakHeap is the heap in use before a run plus the bytes allocated during it,
+	// which is an upper bound for the peak heap size.
+	PeakHeap float64 `json:"peakheap"`
+	// Allocated and Allocs are per run.
+	Allocated float64 `json:"allocated"`
+	Allocs    float64 `json:"allocs"`
+	// RSS is the peak resident set size while running the approach, linux only.
+	RSS float64 `json:"rss,omitempty"`
+}
+
+// SummarizeMemory takes the largest PeakHeap and RSS and averages allocations.
+func SummarizeMemory(runs []Memory) Memory {
+	var r Memory
+	if len(runs) == 0 {
+		return r
+	}
+	for _, run := range runs {
+		r.PeakHeap = math.Max(r.PeakHeap, run.PeakHeap)
+		r.RSS = math.Max(r.RSS, run.RSS)
+		r.Allocated += run.Allocated
+		r.Allocs += run.Allocs
+	}
+	r.Allocated /= float64(len(runs))
+	r.Allocs /= float64(len(runs))
+	return r
+}
+
+func (mem Memory) String() string {
+	return fmt.Sprintf("%.1f MB peak heap\t%.2f MB/run allocated\t%.0f allocs/run\t%.1f MB rss",
+		mem.PeakHeap/1e6, mem.Allocated/1e6, mem.Allocs, mem.RSS/1e6)
+}
+
+// Summarize computes statistics from timings in seconds.
+func (m *Measurement) Summarize(timings []float64) {
+	m.Timings = make([]float64, len(timings))
+	for i, t := range timings {
+		m.Timings[i] = t * 1000
+	}
+
+	sorted := append([]float64{}, m.Timings...)
+	sort.Float64s(sorted)
+
+	m.Min = floats.Min(sorted)
+	m.Max = floats.Max(sorted)
+	m.Average, m.Stdev = stat.MeanStdDev(sorted, nil)
+	m.Median = stat.Quantile(0.5, stat.Empirical, sorted, nil)
+}
+
+const Header = "dataset\tapproach\tmed\tavg\tstdev\tmin\tmax\tsources\tprocs\tedges\tnodes\tmteps\tmnps\tadjbytes\tcycles\tinstructions\tllcmisses\tbranchmisses\tdtlbmisses\tpeakheap\tallocated\tallocs\trss"
+
+// Row formats the measurement as a line in the results table.
+func (m *Measurement) Row() string {
+	return fmt.Sprintf("%v\t%v\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%v\t%v\t%.0f\t%.0f\t%.2f\t%.2f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f",
+		m.Dataset, m.Approach,
+		m.Median, m.Average, m.Stdev, m.Min, m.Max,
+		m.Sources, m.Procs, m.Edges, m.Nodes, m.MTEPS, m.MNPS, m.AdjacencyBytes,
+		m.Counters.Cycles, m.Counters.Instructions, m.Counters.LLCMisses,
+		m.Counters.BranchMisses, m.Counters.DTLBMisses,
+		m.Memory.PeakHeap, m.Memory.Allocated, m.Memory.Allocs, m.Memory.RSS)
+}
+
+// Variant returns the approach name without the procs suffix.
+func (m *Measurement) Variant() string {
+	return strings.TrimSuffix(m.Approach, fmt.Sprintf(" %dx", m.Procs))
+}
+
+type Measurements []Measurement
+
+func (xs Measurements) Dataset(dataset string) Measurements {
+	var rs Measurements
+	for _, x := range xs {
+		if x.Dataset == dataset {
+			rs = append(rs, x)
+		}
+	}
+	return rs
+}
+
+func (xs Measurements) E(dataset, approach string) Measurement {
+	return xs.Entry(Entry{dataset, approach})
+}
+
+// A returns the first measurement of approach, or a zero Measurement when missing.
+func (xs Measurements) A(approach string) Measurement {
+	for _, x := range xs {
+		if x.Approach == approach {
+			return x
+		}
+	}
+	return Measurement{}
+}
+
+// Entry returns the measurement of e, or a zero Measurement when missing.
+func (xs Measurements) Entry(e Entry) Measurement {
+	x, _ := xs.Lookup(e)
+	return x
+}
+
+// Lookup finds the measurement of e and reports whether it exists.
+func (xs Measurements) Lookup(e Entry) (Measurement, bool) {
+	for _, x := range xs {
+		if x.Entry == e {
+			return x, true
+		}
+	}
+	return Measurement{}, false
+}
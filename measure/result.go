@@ -0,0 +1,223 @@
+package measure
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loov/csvcolumn"
+)
+
+// Result is the output of a single harness run.
+type Result struct {
+	Environment  Environment  `json:"environment"`
+	Datasets     []Dataset    `json:"datasets,omitempty"`
+	Measurements Measurements `json:"measurements"`
+}
+
+func ParseFile(name string) (*Result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads either JSON or TSV results,
+// TSV may start with a metadata header written by WriteHeader.
+func Parse(in io.Reader) (*Result, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		result := &Result{}
+		if err := json.Unmarshal(trimmed, result); err != nil {
+			return nil, fmt.Errorf("failed to parse: %w", err)
+		}
+		return result, nil
+	}
+
+	result := &Result{}
+	result.Environment.Flags = map[string]string{}
+	if err := result.parseHeader(data); err != nil {
+		return nil, err
+	}
+	result.Measurements, err = ParseMeasurements(bytes.NewReader(data))
+	return result, err
+}
+
+// ParseMeasurements reads the TSV results table.
+func ParseMeasurements(in io.Reader) (Measurements, error) {
+	data := csvcolumn.NewReader(in)
+	data.LazyQuotes = true
+	data.Comma = '\t'
+	data.Comment = '#'
+
+	dataset, approach := data.String("dataset"), data.String("approach")
+	med, avg, stdev := data.Float64("med"), data.Float64("avg"), data.Float64("stdev")
+	min, max := data.Float64("min"), data.Float64("max")
+	sources, procs := data.Int("sources"), data.Int("procs")
+	edges, nodes := data.Float64("edges"), data.Float64("nodes")
+	mteps, mnps, adjbytes := data.Float64("mteps"), data.Float64("mnps"), data.Float64("adjbytes")
+	cycles, instructions := data.Float64("cycles"), data.Float64("instructions")
+	llcmisses, branchmisses, dtlbmisses := data.Float64("llcmisses"), data.Float64("branchmisses"), data.Float64("dtlbmisses")
+	peakheap, allocated, allocs, rss := data.Float64("peakheap"), data.Float64("allocated"), data.Float64("allocs"), data.Float64("rss")
+
+	var xs Measurements
+	for data.Next() && data.Err() == nil {
+		var x Measurement
+		x.Dataset = *dataset
+		x.Approach = *approach
+		x.Median = *med
+		x.Average = *avg
+		x.Stdev = *stdev
+		x.Min = *min
+		x.Max = *max
+		x.Sources = *sources
+		x.Procs = *procs
+		x.Edges = *edges
+		x.Nodes = *nodes
+		x.MTEPS = *mteps
+		x.MNPS = *mnps
+		x.AdjacencyBytes = *adjbytes
+		x.Counters.Cycles = *cycles
+		x.Counters.Instructions = *instructions
+		x.Counters.LLCMisses = *llcmisses
+		x.Counters.BranchMisses = *branchmisses
+		x.Counters.DTLBMisses = *dtlbmisses
+		x.Memory.PeakHeap = *peakheap
+		x.Memory.Allocated = *allocated
+		x.Memory.Allocs = *allocs
+		x.Memory.RSS = *rss
+		xs = append(xs, x)
+	}
+	if err := data.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	return xs, nil
+}
+
+func splitKeyValue(line, sep string) (key, value string) {
+	p := strings.Index(line, sep)
+	if p < 0 {
+		return strings.TrimSpace(line), ""
+	}
+	return strings.TrimSpace(line[:p]), strings.TrimSpace(line[p+len(sep):])
+}
+
+func (result *Result) parseHeader(data []byte) error {
+	env := &result.Environment
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		key, value := splitKeyValue(strings.TrimPrefix(line, "#"), ":")
+		var err error
+		switch {
+		case key == "host":
+			env.Host = value
+		case key == "os":
+			env.OS = value
+		case key == "arch":
+			env.Arch = value
+		case key == "cpu":
+			env.CPU = value
+		case key == "caches":
+			env.Caches = strings.Split(value, ", ")
+		case key == "numcpu":
+			env.NumCPU, err = strconv.Atoi(value)
+		case key == "gomaxprocs":
+			env.GOMAXPROCS, err = strconv.Atoi(value)
+		case key == "affinity":
+			env.Affinity = value
+		case key == "go":
+			env.GoVersion = value
+		case key == "commit":
+			env.Commit = value
+		case key == "args":
+			env.Args = strings.Fields(value)
+		case key == "time":
+			env.Time, err = time.Parse(time.RFC3339, value)
+		case strings.HasPrefix(key, "flag "):
+			env.Flags[strings.TrimPrefix(key, "flag ")] = value
+		case key == "dataset":
+			err = result.parseDataset(value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse header %q: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (result *Result) parseDataset(value string) error {
+	fields := strings.Split(value, "\t")
+	if len(fields) != 5 {
+		return fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	d := Dataset{Name: fields[0], File: fields[1], Checksum: fields[2]}
+	var err error
+	if d.Nodes, err = strconv.Atoi(fields[3]); err != nil {
+		return err
+	}
+	if d.Edges, err = strconv.Atoi(fields[4]); err != nil {
+		return err
+	}
+	result.Datasets = append(result.Datasets, d)
+	return nil
+}
+
+// WriteHeader writes the metadata header and column names of the TSV table.
+func WriteHeader(w io.Writer, env Environment, datasets []Dataset) {
+	fmt.Fprintf(w, "# host: %v\n", env.Host)
+	fmt.Fprintf(w, "# os: %v\n", env.OS)
+	fmt.Fprintf(w, "# arch: %v\n", env.Arch)
+	fmt.Fprintf(w, "# cpu: %v\n", env.CPU)
+	fmt.Fprintf(w, "# caches: %v\n", strings.Join(env.Caches, ", "))
+	fmt.Fprintf(w, "# numcpu: %v\n", env.NumCPU)
+	fmt.Fprintf(w, "# gomaxprocs: %v\n", env.GOMAXPROCS)
+	fmt.Fprintf(w, "# affinity: %v\n", env.Affinity)
+	fmt.Fprintf(w, "# go: %v\n", env.GoVersion)
+	fmt.Fprintf(w, "# commit: %v\n", env.Commit)
+	fmt.Fprintf(w, "# args: %v\n", strings.Join(env.Args, " "))
+	fmt.Fprintf(w, "# time: %v\n", env.Time.Format(time.RFC3339))
+
+	names := make([]string, 0, len(env.Flags))
+	for name := range env.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "# flag %v: %v\n", name, env.Flags[name])
+	}
+
+	for _, d := range datasets {
+		fmt.Fprintf(w, "# dataset: %v\t%v\t%v\t%v\t%v\n", d.Name, d.File, d.Checksum, d.Nodes, d.Edges)
+	}
+
+	fmt.Fprintln(w, Header)
+}
+
+// WriteJSON writes the result as indented JSON.
+func WriteJSON(w io.Writer, result *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(result)
+}
@@ -0,0 +1,91 @@
+package measure
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testResult() *Result {
+	return &Result{
+		Environment: Environment{
+			Host:       "host",
+			OS:         "linux",
+			Arch:       "amd64",
+			CPU:        "Intel(R) Xeon(R) CPU E5-2670 v3 @ 2.30GHz",
+			Caches:     []string{"L1d 32K", "L2 256K"},
+			NumCPU:     48,
+			GOMAXPROCS: 48,
+			Affinity:   "scatter 0,12,1,13 (2 nodes)",
+			GoVersion:  "go1.13",
+			Commit:     "abc",
+			Args:       []string{"friendster.dat"},
+			Flags:      map[string]string{"N": "10", "cold": "false"},
+			Time:       time.Date(2020, 2, 5, 10, 0, 0, 0, time.UTC),
+		},
+		Datasets: []Dataset{
+			{Name: "friendster", File: "data/friendster.dat", Checksum: "ff", Nodes: 65608366, Edges: 3612134270},
+		},
+		Measurements: Measurements{{
+			Entry:  Entry{"friendster", "marking 4x"},
+			Median: 1.5, Average: 2, Stdev: 0.25, Min: 1, Max: 3,
+			Sources: 1, Procs: 4, Edges: 100, Nodes: 10, MTEPS: 50, MNPS: 5, AdjacencyBytes: 560,
+			Counters: Counters{Cycles: 1000, Instructions: 1500, LLCMisses: 10, BranchMisses: 20, DTLBMisses: 5},
+			Memory:   Memory{PeakHeap: 4096, Allocated: 1024, Allocs: 3, RSS: 8192},
+		}},
+	}
+}
+
+func TestTSV(t *testing.T) {
+	expected := testResult()
+
+	var buf bytes.Buffer
+	WriteHeader(&buf, expected.Environment, expected.Datasets)
+	for _, m := range expected.Measurements {
+		buf.WriteString(m.Row() + "\n")
+	}
+
+	result, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("got %#v\nexpected %#v", result, expected)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	expected := testResult()
+	expected.Measurements[0].Timings = []float64{1, 1.5, 3}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("got %#v\nexpected %#v", result, expected)
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	result, err := Parse(strings.NewReader("dataset\tapproach\tmed\tavg\tstdev\tmin\tmax\n" +
+		"friendster\tbaseline\t66056.44\t72684.60\t16391.31\t54459.00\t92891.77\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Measurements{{
+		Entry:  Entry{"friendster", "baseline"},
+		Median: 66056.44, Average: 72684.60, Stdev: 16391.31, Min: 54459.00, Max: 92891.77,
+	}}
+	if !reflect.DeepEqual(expected, result.Measurements) {
+		t.Errorf("got %#v\nexpected %#v", result.Measurements, expected)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ResetPeakRSS resets the peak resident set size of the process,
+// which needs Linux 4.0 or newer.
+func ResetPeakRSS() {
+	_ = ioutil.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+}
+
+// PeakRSS returns the peak resident set size in bytes since the last reset.
+func PeakRSS() float64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmHWM:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0
+		}
+		kb, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+func ResetPeakRSS() {}
+
+func PeakRSS() float64 { return 0 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+// Output writes measurements in the format selected by -format.
+type Output struct {
+	w      io.Writer
+	result measure.Result
+}
+
+func NewOutput(w io.Writer, datasets []Dataset) (*Output, error) {
+	out := &Output{w: w}
+	out.result.Environment = measure.ReadEnvironment()
+	out.result.Environment.Affinity = affinity.Current().String()
+	for _, dataset := range datasets {
+		out.result.Datasets = append(out.result.Datasets, dataset.Info)
+	}
+
+	switch *format {
+	case "tsv":
+		measure.WriteHeader(w, out.result.Environment, out.result.Datasets)
+	case "json":
+	default:
+		return nil, fmt.Errorf("unknown format %q", *format)
+	}
+	return out, nil
+}
+
+// Add writes a row immediately for TSV, JSON is written on Close.
+// Per iteration data is only kept for JSON.
+func (out *Output) Add(m measure.Measurement) {
+	if *format == "tsv" {
+		fmt.Fprintln(out.w, m.Row())
+	}
+	out.result.Measurements = append(out.result.Measurements, m)
+}
+
+// Close writes JSON results and appends them to -history.
+func (out *Output) Close() error {
+	if *format == "json" {
+		if err := measure.WriteJSON(out.w, &out.result); err != nil {
+			return err
+		}
+	}
+	if *history != "" {
+		return measure.AppendHistory(*history, &out.result)
+	}
+	return nil
+}
+
+// Measure summarizes timings of an approach over all sources.
+func Measure(dataset Dataset, approach string, procs int, timings []float64, throughput *Throughput) measure.Measurement {
+	m := measure.Measurement{
+		Entry:   measure.Entry{Dataset: dataset.Name, Approach: approach},
+		Sources: len(dataset.Sources),
+		Procs:   procs,
+	}
+	m.Summarize(timings)
+
+	run := throughput.PerRun()
+	m.Edges = float64(run.Edges)
+	m.Nodes = float64(run.Nodes)
+	m.MTEPS = throughput.TEPS() / 1e6
+	m.MNPS = throughput.NodesPerSecond() / 1e6
+	m.AdjacencyBytes = float64(run.AdjacencyBytes())
+	return m
+}
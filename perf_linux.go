@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+type perfEvent struct {
+	name   string
+	kind   uint32
+	config uint64
+	add    func(c *measure.Counters, v float64)
+}
+
+func hwCacheMiss(cache uint64) uint64 {
+	return cache | unix.PERF_COUNT_HW_CACHE_OP_READ<<8 | unix.PERF_COUNT_HW_CACHE_RESULT_MISS<<16
+}
+
+var perfEvents = []perfEvent{
+	{"cycles", unix.PERF_TYPE_HARDWARE, unix.PERF_COUNT_HW_CPU_CYCLES,
+		func(c *measure.Counters, v float64) { c.Cycles += v }},
+	{"instructions", unix.PERF_TYPE_HARDWARE, unix.PERF_COUNT_HW_INSTRUCTIONS,
+		func(c *measure.Counters, v float64) { c.Instructions += v }},
+	{"LLC misses", unix.PERF_TYPE_HW_CACHE, hwCacheMiss(unix.PERF_COUNT_HW_CACHE_LL),
+		func(c *measure.Counters, v float64) { c.LLCMisses += v }},
+	{"branch misses", unix.PERF_TYPE_HARDWARE, unix.PERF_COUNT_HW_BRANCH_MISSES,
+		func(c *measure.Counters, v float64) { c.BranchMisses += v }},
+	{"dTLB misses", unix.PERF_TYPE_HW_CACHE, hwCacheMiss(unix.PERF_COUNT_HW_CACHE_DTLB),
+		func(c *measure.Counters, v float64) { c.DTLBMisses += v }},
+}
+
+func (ev *perfEvent) open(tid int) (int, error) {
+	attr := unix.PerfEventAttr{
+		Type:        ev.kind,
+		Size:        uint32(unsafe.Sizeof(unix.PerfEventAttr{})),
+		Config:      ev.config,
+		Bits:        unix.PerfBitDisabled | unix.PerfBitInherit | unix.PerfBitExcludeKernel | unix.PerfBitExcludeHv,
+		Read_format: unix.PERF_FORMAT_TOTAL_TIME_ENABLED | unix.PERF_FORMAT_TOTAL_TIME_RUNNING,
+	}
+	return unix.PerfEventOpen(&attr, tid, -1, -1, unix.PERF_FLAG_FD_CLOEXEC)
+}
+
+// Perf counts hardware events of all threads in the process.
+//
+// Counters are opened for every existing thread on Open and only count
+// between Start and Stop, threads created during the iteration are counted
+// once they exit. Start and Stop don't allocate.
+type Perf struct {
+	events []*perfEvent
+	open   []perfCounter
+}
+
+type perfCounter struct {
+	fd    int
+	event *perfEvent
+}
+
+// OpenPerf checks which events are available,
+// it fails when none of them can be counted.
+func OpenPerf() (*Perf, error) {
+	perf := &Perf{}
+	var lastErr error
+	for i := range perfEvents {
+		ev := &perfEvents[i]
+		fd, err := ev.open(0)
+		if err != nil {
+			lastErr = fmt.Errorf("%v: %w", ev.name, err)
+			continue
+		}
+		unix.Close(fd)
+		perf.events = append(perf.events, ev)
+	}
+	if len(perf.events) == 0 {
+		return nil, fmt.Errorf("perf_event_open unavailable: %w", lastErr)
+	}
+	return perf, nil
+}
+
+// Unavailable returns names of events that cannot be counted.
+func (perf *Perf) Unavailable() []string {
+	var names []string
+	for i := range perfEvents {
+		found := false
+		for _, ev := range perf.events {
+			found = found || ev == &perfEvents[i]
+		}
+		if !found {
+			names = append(names, perfEvents[i].name)
+		}
+	}
+	return names
+}
+
+// Open opens disabled counters for the existing threads.
+func (perf *Perf) Open() {
+	tasks, _ := ioutil.ReadDir("/proc/self/task")
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		for _, ev := range perf.events {
+			fd, err := ev.open(tid)
+			if err != nil {
+				// thread may have exited
+				continue
+			}
+			perf.open = append(perf.open, perfCounter{fd, ev})
+		}
+	}
+}
+
+// Start enables the opened counters.
+func (perf *Perf) Start() {
+	for _, c := range perf.open {
+		_ = unix.IoctlSetInt(c.fd, unix.PERF_EVENT_IOC_ENABLE, 0)
+	}
+}
+
+// Stop disables the opened counters.
+func (perf *Perf) Stop() {
+	for _, c := range perf.open {
+		_ = unix.IoctlSetInt(c.fd, unix.PERF_EVENT_IOC_DISABLE, 0)
+	}
+}
+
+// Read sums and closes the counters.
+func (perf *Perf) Read() measure.Counters {
+	var counters measure.Counters
+	var values [3]uint64 // value, time enabled, time running
+	buf := (*[24]byte)(unsafe.Pointer(&values))[:]
+	for _, c := range perf.open {
+		n, err := unix.Read(c.fd, buf)
+		unix.Close(c.fd)
+		if err != nil || n != len(buf) {
+			continue
+		}
+
+		value, enabled, running := values[0], values[1], values[2]
+		if running == 0 {
+			continue
+		}
+		// scale for multiplexed counters
+		c.event.add(&counters, float64(value)*float64(enabled)/float64(running))
+	}
+	perf.open = perf.open[:0]
+
+	return counters
+}
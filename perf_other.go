@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"errors"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+type Perf struct{}
+
+func OpenPerf() (*Perf, error) {
+	return nil, errors.New("hardware counters are only supported on linux")
+}
+
+func (perf *Perf) Unavailable() []string { return nil }
+
+func (perf *Perf) Open()  {}
+func (perf *Perf) Start() {}
+func (perf *Perf) Stop()  {}
+
+func (perf *Perf) Read() measure.Counters { return measure.Counters{} }
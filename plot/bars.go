@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+
+	"github.com/loov/diagram"
+)
+
+// Bars draws a horizontal bar for each group and series,
+// reference is drawn as a vertical line when non-zero.
+func Bars(filename, title, xlabel string, groups, series []string, values [][]float64, reference float64) error {
+	const (
+		margin     = 30
+		labelwidth = 160
+		width      = 500
+		legend     = 160
+		barheight  = 12
+		pad        = 6
+		head       = 40
+		textheight = 14
+	)
+
+	max := reference
+	for _, row := range values {
+		for _, v := range row {
+			max = math.Max(max, v)
+		}
+	}
+	if max <= 0 {
+		max = 1
+	}
+	max = niceCeil(max)
+
+	groupheight := float64(barheight*len(series) + pad)
+	canvas := diagram.NewSVG(margin*2+labelwidth+width+legend, margin*2+head+groupheight*float64(len(groups)))
+	inner := canvas.Context(canvas.Bounds().Shrink(diagram.Point{X: margin, Y: margin}))
+	grid := inner.Layer(0)
+	bars := inner.Layer(1)
+	text := inner.Layer(2)
+
+	black := color.Gray16{0}
+	px := func(v float64) float64 { return labelwidth + v/max*width }
+	bottom := head + groupheight*float64(len(groups))
+
+	text.Text(title, diagram.Point{X: labelwidth + width/2, Y: 0}, &diagram.Style{
+		Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 0, Y: -1},
+	})
+	text.Text(xlabel, diagram.Point{X: labelwidth + width/2, Y: bottom + textheight*1.5}, &diagram.Style{
+		Fill: black, Size: textheight, Origin: diagram.Point{X: 0, Y: -1},
+	})
+
+	step := niceNumber(max/5, true)
+	for k := 0; float64(k)*step <= max+step/2; k++ {
+		v := float64(k) * step
+		grid.Poly(diagram.Ps(px(v), head, px(v), bottom), &diagram.Style{Stroke: color.Gray16{0xcccc}, Size: 1})
+		text.Text(formatNumber(v, step), diagram.Point{X: px(v), Y: head}, &diagram.Style{
+			Fill: black, Size: textheight * 0.8, Origin: diagram.Point{X: 0, Y: 1},
+		})
+	}
+	if reference != 0 {
+		grid.Poly(diagram.Ps(px(reference), head, px(reference), bottom), &diagram.Style{
+			Stroke: black, Size: 1, Dash: []diagram.Length{4, 4},
+		})
+	}
+
+	y := float64(head)
+	for i, group := range groups {
+		text.Text(group, diagram.Point{X: labelwidth - pad, Y: y + groupheight/2}, &diagram.Style{
+			Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 1, Y: 0},
+		})
+		for k := range series {
+			v := values[i][k]
+			bars.Rect(diagram.Rect{
+				Min: diagram.Point{X: px(0), Y: y + 1},
+				Max: diagram.Point{X: px(v), Y: y + barheight - 1},
+			}, &diagram.Style{Fill: palette[k%len(palette)]})
+			text.Text(fmt.Sprintf("%.2f", v), diagram.Point{X: px(v) + 2, Y: y + barheight/2}, &diagram.Style{
+				Fill: black, Size: barheight * 0.9, Origin: diagram.Point{X: -1, Y: 0},
+			})
+			y += barheight
+		}
+		y += pad
+	}
+
+	drawLegend(text, diagram.Point{X: labelwidth + width + 40, Y: head}, series)
+
+	return ioutil.WriteFile(filename, canvas.Bytes(), 0644)
+}
+
+func drawLegend(canvas diagram.Canvas, at diagram.Point, names []string) {
+	const textheight = 12
+	for i, name := range names {
+		y := at.Y + float64(i)*textheight*1.5
+		canvas.Rect(diagram.Rect{
+			Min: diagram.Point{X: at.X, Y: y - textheight/2},
+			Max: diagram.Point{X: at.X + textheight, Y: y + textheight/2},
+		}, &diagram.Style{Fill: palette[i%len(palette)]})
+		canvas.Text(name, diagram.Point{X: at.X + textheight*1.5, Y: y}, &diagram.Style{
+			Fill: color.Gray16{0}, Size: textheight, Origin: diagram.Point{X: -1, Y: 0},
+		})
+	}
+}
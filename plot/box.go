@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/loov/diagram"
+)
+
+// Box summarizes a distribution of timings.
+type Box struct {
+	Min, Q1, Median, Q3, Max float64
+}
+
+// NewBox computes quartiles, it returns false when there are no samples.
+func NewBox(samples []float64) (Box, bool) {
+	if len(samples) == 0 {
+		return Box{}, false
+	}
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	return Box{
+		Min:    sorted[0],
+		Q1:     quantile(sorted, 0.25),
+		Median: quantile(sorted, 0.5),
+		Q3:     quantile(sorted, 0.75),
+		Max:    sorted[len(sorted)-1],
+	}, true
+}
+
+// quantile interpolates linearly between sorted samples.
+func quantile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	low := int(math.Floor(pos))
+	high := int(math.Ceil(pos))
+	frac := pos - float64(low)
+	return sorted[low]*(1-frac) + sorted[high]*frac
+}
+
+// Boxes draws a horizontal box and whisker plot for each group and series,
+// zero boxes are drawn as missing.
+func Boxes(filename, title string, groups, series []string, boxes [][]Box) error {
+	const (
+		margin     = 30
+		labelwidth = 160
+		width      = 500
+		legend     = 160
+		boxheight  = 14
+		pad        = 6
+		head       = 40
+		textheight = 14
+	)
+
+	max := 0.0
+	for _, row := range boxes {
+		for _, b := range row {
+			max = math.Max(max, b.Max)
+		}
+	}
+	if max <= 0 {
+		max = 1
+	}
+	max = niceCeil(max)
+
+	groupheight := float64(boxheight*len(series) + pad)
+	canvas := diagram.NewSVG(margin*2+labelwidth+width+legend, margin*2+head+groupheight*float64(len(groups)))
+	inner := canvas.Context(canvas.Bounds().Shrink(diagram.Point{X: margin, Y: margin}))
+	grid := inner.Layer(0)
+	shapes := inner.Layer(1)
+	text := inner.Layer(2)
+
+	black := color.Gray16{0}
+	px := func(v float64) float64 { return labelwidth + v/max*width }
+	bottom := head + groupheight*float64(len(groups))
+
+	text.Text(title, diagram.Point{X: labelwidth + width/2, Y: 0}, &diagram.Style{
+		Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 0, Y: -1},
+	})
+
+	step := niceNumber(max/5, true)
+	for k := 0; float64(k)*step <= max+step/2; k++ {
+		v := float64(k) * step
+		grid.Poly(diagram.Ps(px(v), head, px(v), bottom), &diagram.Style{Stroke: color.Gray16{0xcccc}, Size: 1})
+		text.Text(formatTick(v, step), diagram.Point{X: px(v), Y: head}, &diagram.Style{
+			Fill: black, Size: textheight * 0.8, Origin: diagram.Point{X: 0, Y: 1},
+		})
+	}
+
+	y := float64(head)
+	for i, group := range groups {
+		text.Text(group, diagram.Point{X: labelwidth - pad, Y: y + groupheight/2}, &diagram.Style{
+			Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 1, Y: 0},
+		})
+		for k := range series {
+			b := boxes[i][k]
+			c := palette[k%len(palette)]
+			mid := y + boxheight/2
+			line := &diagram.Style{Stroke: c, Size: 1}
+
+			if b == (Box{}) {
+				text.Text("missing", diagram.Point{X: px(0) + 4, Y: mid}, &diagram.Style{
+					Fill: black, Size: boxheight * 0.8, Origin: diagram.Point{X: -1, Y: 0},
+				})
+				y += boxheight
+				continue
+			}
+
+			shapes.Poly(diagram.Ps(px(b.Min), mid, px(b.Q1), mid), line)
+			shapes.Poly(diagram.Ps(px(b.Q3), mid, px(b.Max), mid), line)
+			shapes.Poly(diagram.Ps(px(b.Min), y+3, px(b.Min), y+boxheight-3), line)
+			shapes.Poly(diagram.Ps(px(b.Max), y+3, px(b.Max), y+boxheight-3), line)
+			shapes.Rect(diagram.Rect{
+				Min: diagram.Point{X: px(b.Q1), Y: y + 1},
+				Max: diagram.Point{X: math.Max(px(b.Q3), px(b.Q1)+1), Y: y + boxheight - 1},
+			}, &diagram.Style{Fill: c})
+			shapes.Poly(diagram.Ps(px(b.Median), y, px(b.Median), y+boxheight), &diagram.Style{Stroke: black, Size: 2})
+
+			text.Text(fmt.Sprintf("%.2f", b.Median), diagram.Point{X: px(b.Max) + 4, Y: mid}, &diagram.Style{
+				Fill: black, Size: boxheight * 0.8, Origin: diagram.Point{X: -1, Y: 0},
+			})
+			y += boxheight
+		}
+		y += pad
+	}
+
+	drawLegend(text, diagram.Point{X: labelwidth + width + 60, Y: head}, series)
+
+	return ioutil.WriteFile(filename, canvas.Bytes(), 0644)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"strconv"
+
+	"github.com/loov/diagram"
+)
+
+var palette = []color.Color{
+	color.RGBA{0x1f, 0x77, 0xb4, 0xff},
+	color.RGBA{0xff, 0x7f, 0x0e, 0xff},
+	color.RGBA{0x2c, 0xa0, 0x2c, 0xff},
+	color.RGBA{0xd6, 0x27, 0x28, 0xff},
+	color.RGBA{0x94, 0x67, 0xbd, 0xff},
+	color.RGBA{0x8c, 0x56, 0x4b, 0xff},
+	color.RGBA{0xe3, 0x77, 0xc2, 0xff},
+	color.RGBA{0x7f, 0x7f, 0x7f, 0xff},
+	color.RGBA{0xbc, 0xbd, 0x22, 0xff},
+	color.RGBA{0x17, 0xbe, 0xcf, 0xff},
+}
+
+type Series struct {
+	Name string
+	X, Y []float64
+}
+
+// LineChart plots series on linear axes starting from zero,
+// reference is drawn dashed when not nil.
+func LineChart(filename, title, xlabel, ylabel string, reference *Series, series ...Series) error {
+	const (
+		margin     = 40
+		width      = 600
+		height     = 400
+		legend     = 160
+		textheight = 14
+		ticks      = 5
+	)
+
+	var xmax, ymax float64
+	all := series
+	if reference != nil {
+		all = append([]Series{*reference}, series...)
+	}
+	for _, s := range all {
+		for i := range s.X {
+			xmax = math.Max(xmax, s.X[i])
+			ymax = math.Max(ymax, s.Y[i])
+		}
+	}
+	if xmax == 0 || ymax == 0 {
+		return fmt.Errorf("%v: no data", filename)
+	}
+	xstep, ystep := niceNumber(xmax/ticks, true), niceNumber(ymax*1.05/ticks, true)
+	xmax, ymax = math.Ceil(xmax/xstep)*xstep, math.Ceil(ymax*1.05/ystep)*ystep
+
+	canvas := diagram.NewSVG(margin*2+width+legend, margin*2+height)
+	inner := canvas.Context(canvas.Bounds().Shrink(diagram.Point{X: margin, Y: margin}))
+	grid := inner.Layer(0)
+	lines := inner.Layer(1)
+	text := inner.Layer(2)
+
+	black := color.Gray16{0}
+	px := func(x float64) float64 { return x / xmax * width }
+	py := func(y float64) float64 { return height - y/ymax*height }
+
+	text.Text(title, diagram.Point{X: width / 2, Y: -textheight}, &diagram.Style{
+		Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 0, Y: -1},
+	})
+	text.Text(xlabel, diagram.Point{X: width / 2, Y: height + textheight*2}, &diagram.Style{
+		Fill: black, Size: textheight, Origin: diagram.Point{X: 0, Y: -1},
+	})
+	text.Text(ylabel, diagram.Point{X: -textheight * 2, Y: height / 2}, &diagram.Style{
+		Fill: black, Size: textheight, Rotation: -math.Pi / 2, Origin: diagram.Point{X: 0, Y: 1},
+	})
+
+	gridStyle := &diagram.Style{Stroke: color.Gray16{0xcccc}, Size: 1}
+	for k := 0; float64(k)*xstep <= xmax+xstep/2; k++ {
+		x := float64(k) * xstep
+		grid.Poly(diagram.Ps(px(x), py(0), px(x), py(ymax)), gridStyle)
+		text.Text(formatNumber(x, xstep), diagram.Point{X: px(x), Y: height + 2}, &diagram.Style{
+			Fill: black, Size: textheight * 0.8, Origin: diagram.Point{X: 0, Y: -1},
+		})
+	}
+	for k := 0; float64(k)*ystep <= ymax+ystep/2; k++ {
+		y := float64(k) * ystep
+		grid.Poly(diagram.Ps(px(0), py(y), px(xmax), py(y)), gridStyle)
+		text.Text(formatNumber(y, ystep), diagram.Point{X: -2, Y: py(y)}, &diagram.Style{
+			Fill: black, Size: textheight * 0.8, Origin: diagram.Point{X: 1, Y: 0},
+		})
+	}
+
+	points := func(s Series) []diagram.Point {
+		ps := make([]diagram.Point, len(s.X))
+		for i := range s.X {
+			ps[i] = diagram.Point{X: px(s.X[i]), Y: py(s.Y[i])}
+		}
+		return ps
+	}
+
+	legendY := float64(0)
+	legendEntry := func(name string, style *diagram.Style) {
+		lines.Poly(diagram.Ps(width+10, legendY, width+30, legendY), style)
+		text.Text(name, diagram.Point{X: width + 35, Y: legendY}, &diagram.Style{
+			Fill: black, Size: textheight * 0.9, Origin: diagram.Point{X: -1, Y: 0},
+		})
+		legendY += textheight * 1.4
+	}
+
+	if reference != nil {
+		style := &diagram.Style{Stroke: black, Size: 1, Dash: []diagram.Length{4, 4}}
+		lines.Poly(points(*reference), style)
+		legendEntry(reference.Name, style)
+	}
+	for i, s := range series {
+		style := &diagram.Style{Stroke: palette[i%len(palette)], Size: 2}
+		lines.Poly(points(s), style)
+		for _, p := range points(s) {
+			lines.Rect(diagram.Rect{
+				Min: p.Sub(diagram.Point{X: 2, Y: 2}),
+				Max: p.Add(diagram.Point{X: 2, Y: 2}),
+			}, &diagram.Style{Fill: palette[i%len(palette)]})
+		}
+		legendEntry(s.Name, style)
+	}
+
+	return ioutil.WriteFile(filename, canvas.Bytes(), 0644)
+}
+
+// formatNumber formats v with as many decimals as step needs.
+func formatNumber(v, step float64) string {
+	decimals := int(math.Max(0, -math.Floor(math.Log10(step))))
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
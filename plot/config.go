@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+// Config describes figures rendered from result files.
+type Config struct {
+	Inputs  []Input  `json:"inputs"`
+	Figures []Figure `json:"figures"`
+}
+
+// Input selects a dataset from a result file.
+type Input struct {
+	File    string `json:"file"`
+	Dataset string `json:"dataset,omitempty"`
+	Title   string `json:"title,omitempty"`
+	// Max is the axis limit in milliseconds, zero scales to the data.
+	Max float64 `json:"max,omitempty"`
+
+	result *measure.Result
+}
+
+// Figure is a single output file.
+type Figure struct {
+	Output string `json:"output"`
+	// Type is one of mirror (default), box, speedup, procs, levels or heatmap.
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+	// Titles overrides the input titles.
+	Titles []string `json:"titles,omitempty"`
+	// Approaches defaults to all approaches of the first input,
+	// for procs they are approach names without the procs suffix
+	// and for levels they filter traces by name.
+	Approaches []Approach `json:"approaches,omitempty"`
+	// Baseline is the approach used for speedup, defaults to "baseline".
+	Baseline string `json:"baseline,omitempty"`
+	// Trace is the file written by -trace, used by levels.
+	Trace string `json:"trace,omitempty"`
+}
+
+// Approach selects measurements, Names are per input
+// and a single name is used for all inputs.
+type Approach struct {
+	Label string   `json:"label"`
+	Names []string `json:"names"`
+}
+
+// UnmarshalJSON allows specifying an approach as a plain name.
+func (approach *Approach) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*approach = Approach{Label: name, Names: []string{name}}
+		return nil
+	}
+
+	type plain Approach
+	return json.Unmarshal(data, (*plain)(approach))
+}
+
+func (approach *Approach) Name(input int) string {
+	if len(approach.Names) == 0 {
+		return approach.Label
+	}
+	if input < len(approach.Names) {
+		return approach.Names[input]
+	}
+	return approach.Names[0]
+}
+
+// LoadConfig reads a config, the input, trace and output
+// files in it are relative to the config file.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%v: %w", filename, err)
+	}
+
+	dir := filepath.Dir(filename)
+	relative := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+	for i := range config.Inputs {
+		config.Inputs[i].File = relative(config.Inputs[i].File)
+	}
+	for i := range config.Figures {
+		config.Figures[i].Output = relative(config.Figures[i].Output)
+		config.Figures[i].Trace = relative(config.Figures[i].Trace)
+	}
+	return config, nil
+}
+
+// Load parses the inputs and fills in missing datasets and titles.
+func (config *Config) Load() error {
+	for i := range config.Inputs {
+		input := &config.Inputs[i]
+
+		var err error
+		input.result, err = measure.ParseFile(input.File)
+		if err != nil {
+			return fmt.Errorf("%v: %w", input.File, err)
+		}
+		if len(input.result.Measurements) == 0 {
+			return fmt.Errorf("%v: no measurements", input.File)
+		}
+
+		if input.Dataset == "" {
+			input.Dataset = input.result.Measurements[0].Dataset
+		}
+		if input.Title == "" {
+			input.Title = input.Dataset
+			if cpu := input.result.Environment.CPU; cpu != "" {
+				input.Title = cpu + " | " + input.Dataset
+			}
+		}
+	}
+	return nil
+}
+
+// Measurements returns measurements of the selected dataset.
+func (input *Input) Measurements() measure.Measurements {
+	return input.result.Measurements.Dataset(input.Dataset)
+}
+
+// Render writes all figures.
+func (config *Config) Render() error {
+	for _, figure := range config.Figures {
+		if err := config.render(&figure); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (config *Config) render(figure *Figure) error {
+	if figure.Type == "levels" {
+		return config.renderLevels(figure)
+	}
+	if len(config.Inputs) == 0 {
+		return fmt.Errorf("%v: no inputs", figure.Output)
+	}
+
+	titles := make([]string, len(config.Inputs))
+	for i, input := range config.Inputs {
+		titles[i] = input.Title
+		if i < len(figure.Titles) {
+			titles[i] = figure.Titles[i]
+		}
+	}
+
+	approaches := figure.Approaches
+	if len(approaches) == 0 {
+		approaches = config.allApproaches(figure.Type == "procs")
+	}
+
+	switch figure.Type {
+	case "procs":
+		return config.renderProcs(figure, titles, approaches)
+	case "heatmap":
+		return config.renderHeatmap(figure, approaches)
+	}
+
+	var labels []string
+	var measurements [][]measure.Measurement
+	for _, approach := range approaches {
+		var row []measure.Measurement
+		for i, input := range config.Inputs {
+			name := approach.Name(i)
+			m, ok := input.result.Measurements.Lookup(measure.Entry{Dataset: input.Dataset, Approach: name})
+			if !ok {
+				fmt.Fprintf(os.Stderr, "warning: %v: missing %q in %v\n", figure.Output, name, input.File)
+			}
+			row = append(row, m)
+		}
+		labels = append(labels, approach.Label)
+		measurements = append(measurements, row)
+	}
+
+	switch figure.Type {
+	case "", "mirror":
+		sides := make([]Side, len(config.Inputs))
+		for i, input := range config.Inputs {
+			sides[i] = Side{Title: titles[i], Max: input.Max}
+		}
+		lines := make([]Line, len(labels))
+		for i := range labels {
+			lines[i] = Line{Name: labels[i], Measurements: measurements[i]}
+		}
+		return Mirror(figure.Output, sides, lines)
+
+	case "box":
+		boxes := make([][]Box, len(labels))
+		for i, row := range measurements {
+			for k, m := range row {
+				b, ok := NewBox(m.Timings)
+				if !ok && m.Approach != "" {
+					return fmt.Errorf("%v: %q in %v has no timings, box needs -format json results",
+						figure.Output, m.Approach, config.Inputs[k].File)
+				}
+				boxes[i] = append(boxes[i], b)
+			}
+		}
+		return Boxes(figure.Output, figure.Title, labels, titles, boxes)
+
+	case "speedup":
+		baseline := figure.Baseline
+		if baseline == "" {
+			baseline = "baseline"
+		}
+		values := make([][]float64, len(labels))
+		for i, row := range measurements {
+			for k, m := range row {
+				base := config.Inputs[k].Measurements().A(baseline)
+				if base.Approach == "" {
+					return fmt.Errorf("%v: missing baseline %q in %v", figure.Output, baseline, config.Inputs[k].File)
+				}
+				speedup := 0.0
+				if m.Approach != "" {
+					speedup = base.Median / m.Median
+				}
+				values[i] = append(values[i], speedup)
+			}
+		}
+		return Bars(figure.Output, figure.Title, "speedup vs "+baseline, labels, titles, values, 1)
+	}
+
+	return fmt.Errorf("%v: unknown figure type %q", figure.Output, figure.Type)
+}
+
+// allApproaches lists approaches of the first input,
+// variants lists each parallel approach once without the procs suffix.
+func (config *Config) allApproaches(variants bool) []Approach {
+	var approaches []Approach
+	seen := map[string]bool{}
+	for _, m := range config.Inputs[0].Measurements() {
+		name := m.Approach
+		if variants {
+			if m.Procs == 0 {
+				continue
+			}
+			name = m.Variant()
+		}
+		if !seen[name] {
+			seen[name] = true
+			approaches = append(approaches, Approach{Label: name, Names: []string{name}})
+		}
+	}
+	return approaches
+}
+
+func (config *Config) renderProcs(figure *Figure, titles []string, approaches []Approach) error {
+	var series []Series
+	for i, input := range config.Inputs {
+		for _, approach := range approaches {
+			s := Series{Name: approach.Label}
+			if len(config.Inputs) > 1 {
+				s.Name = titles[i] + ": " + approach.Label
+			}
+
+			ms := measure.Measurements{}
+			for _, m := range input.Measurements() {
+				if m.Procs > 0 && m.Variant() == approach.Name(i) {
+					ms = append(ms, m)
+				}
+			}
+			sort.Slice(ms, func(i, k int) bool { return ms[i].Procs < ms[k].Procs })
+			for _, m := range ms {
+				s.X = append(s.X, float64(m.Procs))
+				s.Y = append(s.Y, m.Median)
+			}
+			if len(s.X) > 0 {
+				series = append(series, s)
+			}
+		}
+	}
+	return LineChart(figure.Output, figure.Title, "goroutines", "median ms", nil, series...)
+}
+
+func (config *Config) renderHeatmap(figure *Figure, approaches []Approach) error {
+	result := config.Inputs[0].result
+
+	var datasets []string
+	seen := map[string]bool{}
+	for _, m := range result.Measurements {
+		if !seen[m.Dataset] {
+			seen[m.Dataset] = true
+			datasets = append(datasets, m.Dataset)
+		}
+	}
+
+	best := make([]float64, len(datasets))
+	for k, dataset := range datasets {
+		best[k] = math.Inf(1)
+		for _, m := range result.Measurements.Dataset(dataset) {
+			best[k] = math.Min(best[k], m.Median)
+		}
+	}
+
+	rows := make([]string, len(approaches))
+	values := make([][]float64, len(approaches))
+	labels := make([][]string, len(approaches))
+	for i, approach := range approaches {
+		rows[i] = approach.Label
+		for k, dataset := range datasets {
+			m := result.Measurements.E(dataset, approach.Name(0))
+			if m.Approach == "" {
+				values[i] = append(values[i], 0)
+				labels[i] = append(labels[i], "-")
+				continue
+			}
+			ratio := m.Median / best[k]
+			values[i] = append(values[i], ratio)
+			labels[i] = append(labels[i], fmt.Sprintf("%v %.1fx", formatValue(m.Median, m.Median), ratio))
+		}
+	}
+
+	return Heatmap(figure.Output, figure.Title, rows, datasets, values, labels)
+}
+
+func (config *Config) renderLevels(figure *Figure) error {
+	if figure.Trace == "" {
+		return fmt.Errorf("%v: levels needs a trace file", figure.Output)
+	}
+	traces, err := ParseTrace(figure.Trace)
+	if err != nil {
+		return err
+	}
+
+	var series []Series
+	for _, trace := range traces {
+		include := len(figure.Approaches) == 0
+		for _, approach := range figure.Approaches {
+			include = include || strings.Contains(trace.Name, approach.Label)
+		}
+		if include {
+			series = append(series, Series{Name: trace.Name, X: trace.Levels, Y: trace.Frontier})
+		}
+	}
+	if len(series) == 0 {
+		return fmt.Errorf("%v: no matching traces in %v", figure.Output, figure.Trace)
+	}
+	return LineChart(figure.Output, figure.Title, "level", "frontier size", nil, series...)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApproachUnmarshalJSON(t *testing.T) {
+	var approaches []Approach
+	data := `["baseline", {"label": "unroll", "names": ["unroll 8", "unroll 8 4"]}]`
+	if err := json.Unmarshal([]byte(data), &approaches); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Approach{
+		{Label: "baseline", Names: []string{"baseline"}},
+		{Label: "unroll", Names: []string{"unroll 8", "unroll 8 4"}},
+	}
+	if !reflect.DeepEqual(approaches, expected) {
+		t.Errorf("got %+v, expected %+v", approaches, expected)
+	}
+
+	for input, name := range []string{"unroll 8", "unroll 8 4", "unroll 8"} {
+		if got := approaches[1].Name(input); got != name {
+			t.Errorf("input %d: got %q, expected %q", input, got, name)
+		}
+	}
+	if got := (&Approach{Label: "sort"}).Name(1); got != "sort" {
+		t.Errorf("got %q, expected the label", got)
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), &approaches); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	abs := filepath.Join(dir, "abs.svg")
+	data := `{
+		"inputs": [{"file": "../results/a.txt", "dataset": "sg"}],
+		"figures": [
+			{"output": "a.svg", "approaches": ["baseline"]},
+			{"output": "` + filepath.ToSlash(abs) + `", "type": "levels", "trace": "levels.csv"}
+		]
+	}`
+	filename := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := config.Inputs[0].File, filepath.Join(dir, "../results/a.txt"); got != exp {
+		t.Errorf("input: got %q, expected %q", got, exp)
+	}
+	if got, exp := config.Figures[0].Output, filepath.Join(dir, "a.svg"); got != exp {
+		t.Errorf("output: got %q, expected %q", got, exp)
+	}
+	if got := config.Figures[0].Trace; got != "" {
+		t.Errorf("trace: got %q, expected none", got)
+	}
+	if got := config.Figures[1].Output; got != abs {
+		t.Errorf("absolute output: got %q, expected %q", got, abs)
+	}
+	if got, exp := config.Figures[1].Trace, filepath.Join(dir, "levels.csv"); got != exp {
+		t.Errorf("trace: got %q, expected %q", got, exp)
+	}
+}
+
+func TestConfigFromFlags(t *testing.T) {
+	defer func(d, a, t, m, o string) {
+		*datasets, *approaches, *titles, *maxes, *output = d, a, t, m, o
+	}(*datasets, *approaches, *titles, *maxes, *output)
+
+	*datasets, *approaches, *titles, *maxes, *output = "sg", "baseline,unroll 8", "old,new", "100,2.5", "cmp.svg"
+	config, err := configFromFlags([]string{"old.txt", "new.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Config{
+		Inputs: []Input{
+			{File: "old.txt", Dataset: "sg", Title: "old", Max: 100},
+			{File: "new.txt", Title: "new", Max: 2.5},
+		},
+		Figures: []Figure{{
+			Output:   "cmp.svg",
+			Type:     *figureType,
+			Baseline: *baseline,
+			Approaches: []Approach{
+				{Label: "baseline", Names: []string{"baseline"}},
+				{Label: "unroll 8", Names: []string{"unroll 8"}},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("got %+v\nexpected %+v", config, expected)
+	}
+
+	*maxes = "fast"
+	if _, err := configFromFlags([]string{"old.txt"}); err == nil {
+		t.Error("expected an error for an invalid -max")
+	}
+}
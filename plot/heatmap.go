@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image/color"
+	"io/ioutil"
+	"math"
+
+	"github.com/loov/diagram"
+)
+
+// Heatmap draws a table of cells colored by value from green (low) to red (high)
+// on a logarithmic scale, labels are written inside the cells.
+func Heatmap(filename, title string, rows, cols []string, values [][]float64, labels [][]string) error {
+	const (
+		margin     = 30
+		labelwidth = 160
+		cellwidth  = 110
+		cellheight = 22
+		head       = 60
+		textheight = 12
+	)
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, row := range values {
+		for _, v := range row {
+			if v > 0 {
+				low = math.Min(low, math.Log(v))
+				high = math.Max(high, math.Log(v))
+			}
+		}
+	}
+
+	canvas := diagram.NewSVG(
+		margin*2+labelwidth+cellwidth*float64(len(cols)),
+		margin*2+head+cellheight*float64(len(rows)))
+	inner := canvas.Context(canvas.Bounds().Shrink(diagram.Point{X: margin, Y: margin}))
+	cells := inner.Layer(0)
+	text := inner.Layer(1)
+
+	black := color.Gray16{0}
+	text.Text(title, diagram.Point{X: labelwidth, Y: 0}, &diagram.Style{
+		Fill: black, Size: textheight * 1.2, Font: "bold", Origin: diagram.Point{X: -1, Y: -1},
+	})
+
+	for k, col := range cols {
+		text.Text(col, diagram.Point{X: labelwidth + cellwidth*(float64(k)+0.5), Y: head - 4}, &diagram.Style{
+			Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 0, Y: 1},
+		})
+	}
+
+	for i, row := range rows {
+		y := head + cellheight*float64(i)
+		text.Text(row, diagram.Point{X: labelwidth - 4, Y: y + cellheight/2}, &diagram.Style{
+			Fill: black, Size: textheight, Font: "bold", Origin: diagram.Point{X: 1, Y: 0},
+		})
+		for k := range cols {
+			x := labelwidth + cellwidth*float64(k)
+			r := diagram.Rect{
+				Min: diagram.Point{X: x + 1, Y: y + 1},
+				Max: diagram.Point{X: x + cellwidth - 1, Y: y + cellheight - 1},
+			}
+
+			v := values[i][k]
+			fill := color.Color(color.Gray16{0xeeee})
+			if v > 0 {
+				t := 0.0
+				if high > low {
+					t = (math.Log(v) - low) / (high - low)
+				}
+				fill = color.RGBA{uint8(80 + 175*t), uint8(200 - 140*t), 80, 0xff}
+			}
+			cells.Rect(r, &diagram.Style{Fill: fill})
+			text.Text(labels[i][k], diagram.Point{X: x + cellwidth/2, Y: y + cellheight/2}, &diagram.Style{
+				Fill: black, Size: textheight, Origin: diagram.Point{X: 0, Y: 0},
+			})
+		}
+	}
+
+	return ioutil.WriteFile(filename, canvas.Bytes(), 0644)
+}
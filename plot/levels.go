@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/loov/csvcolumn"
+)
+
+// LevelTrace is the frontier size per level of a single traced search.
+type LevelTrace struct {
+	Name     string
+	Levels   []float64
+	Frontier []float64
+	Edges    []float64
+}
+
+// ParseTrace reads the per-level CSV written by the -trace flag.
+func ParseTrace(filename string) ([]*LevelTrace, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := csvcolumn.NewReader(f)
+	name, level, worker := data.String("name"), data.Int("level"), data.Int("worker")
+	frontier, edges := data.Int("frontier"), data.Int("edges")
+
+	var traces []*LevelTrace
+	byName := map[string]*LevelTrace{}
+	for data.Next() && data.Err() == nil {
+		trace, ok := byName[*name]
+		if !ok {
+			trace = &LevelTrace{Name: *name}
+			byName[*name] = trace
+			traces = append(traces, trace)
+		}
+		if *worker == 0 {
+			trace.Levels = append(trace.Levels, float64(*level))
+			trace.Frontier = append(trace.Frontier, float64(*frontier))
+			trace.Edges = append(trace.Edges, 0)
+		}
+		if n := len(trace.Edges); n > 0 {
+			trace.Edges[n-1] += float64(*edges)
+		}
+	}
+	if err := data.Err(); err != nil {
+		return nil, fmt.Errorf("%v: %w", filename, err)
+	}
+	return traces, nil
+}
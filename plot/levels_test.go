@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
+)
+
+func TestParseTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "levels.csv")
+	data := tracing.CSVHeader + "\n" +
+		`"busy 2x",1,0,1,10,8,0,100,0,50` + "\n" +
+		`"busy 2x",1,1,1,0,0,0,0,0,150` + "\n" +
+		`"busy 2x",2,0,8,30,20,3,100,20,50` + "\n" +
+		`"busy 2x",2,1,8,34,25,0,100,20,50` + "\n" +
+		`"ordering",1,0,1,10,8,0,100,20,0` + "\n"
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	traces, err := ParseTrace(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*LevelTrace{
+		{Name: "busy 2x", Levels: []float64{1, 2}, Frontier: []float64{1, 8}, Edges: []float64{10, 64}},
+		{Name: "ordering", Levels: []float64{1}, Frontier: []float64{1}, Edges: []float64{10}},
+	}
+	if !reflect.DeepEqual(traces, expected) {
+		for _, trace := range traces {
+			t.Logf("%+v", trace)
+		}
+		t.Error("traces differ")
+	}
+
+	if _, err := ParseTrace(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+	"github.com/loov/diagram"
+)
+
+// Side is one half of a mirrored bar chart.
+type Side struct {
+	Title string
+	// Max is the axis limit in milliseconds, zero scales to the data.
+	Max float64
+}
+
+// Line is a single approach measured on each side,
+// missing measurements have an empty Approach.
+type Line struct {
+	Name         string
+	Measurements []measure.Measurement
+}
+
+// alpha is the significance level for highlighting bars that are
+// indistinguishable from their neighbors.
+const alpha = 0.05
+
+// Mirror draws medians as horizontal bars, with two sides the
+// first grows to the left and the second to the right.
+// Each bar has min/max whiskers and a stdev band.
+func Mirror(filename string, sides []Side, lines []Line) error {
+	const (
+		head       = 20
+		sidewidth  = 350
+		height     = 16
+		textheight = 16
+		textwidth  = textheight * 12
+		pad        = 2
+	)
+
+	if len(sides) < 1 || len(sides) > 2 {
+		return fmt.Errorf("%v: mirror chart needs one or two sides, got %d", filename, len(sides))
+	}
+
+	scales := make([]float64, len(sides))
+	for i, side := range sides {
+		scales[i] = side.Max
+		if scales[i] == 0 {
+			for _, line := range lines {
+				m := line.Measurements[i]
+				scales[i] = math.Max(scales[i], m.Median+m.Stdev)
+			}
+			if scales[i] <= 0 {
+				scales[i] = 1
+			}
+			scales[i] = niceCeil(scales[i])
+		}
+	}
+
+	// noisy[k][i] is set when line k on side i can't be told apart from the line above or below
+	noisy := make([][]bool, len(lines))
+	anyNoisy := false
+	for k := range lines {
+		noisy[k] = make([]bool, len(sides))
+		for i := range sides {
+			m := lines[k].Measurements[i]
+			for _, j := range []int{k - 1, k + 1} {
+				if j < 0 || j >= len(lines) || m.Approach == "" {
+					continue
+				}
+				if other := lines[j].Measurements[i]; other.Approach != "" && measure.Indistinguishable(m, other, alpha) {
+					noisy[k][i] = true
+					anyNoisy = true
+				}
+			}
+		}
+	}
+
+	const margin = 30
+	canvaswidth := float64(sidewidth*len(sides) + textwidth + margin*2)
+	canvasheight := float64(head + (height+2*pad)*len(lines) + margin*2)
+	if anyNoisy {
+		canvasheight += textheight
+	}
+
+	canvas := diagram.NewSVG(canvaswidth, canvasheight)
+	r := canvas.Bounds().Shrink(diagram.Point{X: margin, Y: margin})
+
+	inner := canvas.Context(r)
+	base := inner.Layer(0)
+	grid := inner.Layer(1)
+	text := inner.Layer(2)
+
+	var xl float64 = sidewidth * float64(len(sides)-1)
+	var xr float64 = xl + textwidth
+
+	black := color.Gray16{0}
+	highlight := color.RGBA{0xd0, 0x70, 0x00, 0xff}
+	band := color.NRGBA{0xff, 0xff, 0xff, 0x80}
+	whisker := color.Gray16{0x8888}
+
+	// position computes the bar end for value on side i
+	position := func(i int, v float64) float64 {
+		if i == 0 && len(sides) == 2 {
+			return xl - v*sidewidth/scales[i]
+		}
+		return xr + v*sidewidth/scales[i]
+	}
+
+	for i, side := range sides {
+		x := xr
+		if i == 0 && len(sides) == 2 {
+			x = xl
+		}
+		text.Text(side.Title, diagram.Point{X: x, Y: -pad},
+			&diagram.Style{
+				Fill:   black,
+				Size:   14,
+				Origin: diagram.Point{X: 0, Y: -1},
+			})
+
+		step := niceNumber(scales[i]/10, true)
+		for k := 0; float64(k)*step <= scales[i]+step/2; k++ {
+			v := float64(k) * step
+			x := position(i, v)
+			grid.Poly(diagram.Ps(
+				x, head,
+				x, grid.Bounds().Size().Y,
+			), &diagram.Style{
+				Stroke: color.Gray16{0x4444},
+				Size:   1,
+			})
+
+			grid.Text(formatTick(v, step),
+				diagram.Point{X: x, Y: head},
+				&diagram.Style{
+					Fill:   black,
+					Size:   14,
+					Origin: diagram.Point{X: 0, Y: 1},
+				})
+		}
+	}
+
+	y := float64(head)
+	for k, line := range lines {
+		y += pad
+		textY := y + height - pad
+
+		for i, m := range line.Measurements {
+			label := diagram.Point{Y: textY}
+			var origin float64
+			// values are written next to the axis in the center column
+			if i == 0 && len(sides) == 2 {
+				label.X, origin = xl+pad, -1
+			} else {
+				label.X, origin = xr-pad, 1
+			}
+
+			if m.Approach == "" {
+				text.Text("missing", label, &diagram.Style{
+					Fill:   highlight,
+					Size:   textheight * 0.9,
+					Origin: diagram.Point{X: origin, Y: 1},
+				})
+				continue
+			}
+
+			// span converts a range of values to bar coordinates clipped to the axis
+			span := func(low, high, y0, y1 float64) diagram.Rect {
+				low = math.Max(low, 0)
+				high = math.Min(high, scales[i])
+				x0, x1 := position(i, low), position(i, high)
+				return diagram.Rect{
+					Min: diagram.Point{X: math.Min(x0, x1), Y: y0},
+					Max: diagram.Point{X: math.Max(x0, x1), Y: y1},
+				}
+			}
+
+			fill := color.Color(black)
+			if noisy[k][i] {
+				fill = highlight
+			}
+			base.Rect(span(0, m.Median, y, y+height), &diagram.Style{Fill: fill})
+			if m.Stdev > 0 {
+				base.Rect(span(m.Median-m.Stdev, m.Median+m.Stdev, y+height/4, y+height*3/4), &diagram.Style{Fill: band})
+			}
+			if m.Max > m.Min {
+				r := span(m.Min, m.Max, y+pad, y+height-pad)
+				mid := y + height/2
+				style := &diagram.Style{Stroke: whisker, Size: 1}
+				base.Poly(diagram.Ps(r.Min.X, mid, r.Max.X, mid), style)
+				if m.Min >= 0 && m.Min <= scales[i] {
+					x := position(i, m.Min)
+					base.Poly(diagram.Ps(x, r.Min.Y, x, r.Max.Y), style)
+				}
+				if m.Max <= scales[i] {
+					x := position(i, m.Max)
+					base.Poly(diagram.Ps(x, r.Min.Y, x, r.Max.Y), style)
+				}
+			}
+
+			text.Text(formatValue(m.Median, scales[i]), label, &diagram.Style{
+				Fill:   black,
+				Size:   textheight * 0.9,
+				Origin: diagram.Point{X: origin, Y: 1},
+			})
+		}
+
+		text.Text(line.Name, diagram.Point{
+			X: (xl + xr) / 2,
+			Y: textY,
+		}, &diagram.Style{
+			Fill:   black,
+			Size:   textheight,
+			Font:   "bold",
+			Origin: diagram.Point{X: 0, Y: 1},
+		})
+
+		y += height + pad
+	}
+
+	if anyNoisy {
+		text.Text("highlighted bars are within noise of a neighbor", diagram.Point{X: (xl + xr) / 2, Y: y + pad}, &diagram.Style{
+			Fill:   highlight,
+			Size:   textheight * 0.8,
+			Origin: diagram.Point{X: 0, Y: -1},
+		})
+	}
+
+	return ioutil.WriteFile(filename, canvas.Bytes(), 0644)
+}
+
+// niceNumber finds a 1, 2 or 5 multiple of a power of ten near span.
+func niceNumber(span float64, round bool) float64 {
+	if span <= 0 {
+		return 1
+	}
+	exp := math.Floor(math.Log10(span))
+	frac := span / math.Pow(10, exp)
+
+	var nice float64
+	if round {
+		switch {
+		case frac < 1.5:
+			nice = 1
+		case frac < 3:
+			nice = 2
+		case frac < 7:
+			nice = 5
+		default:
+			nice = 10
+		}
+	} else {
+		switch {
+		case frac <= 1:
+			nice = 1
+		case frac <= 2:
+			nice = 2
+		case frac <= 5:
+			nice = 5
+		default:
+			nice = 10
+		}
+	}
+	return nice * math.Pow(10, exp)
+}
+
+// niceCeil rounds v up to a multiple of a nice step.
+func niceCeil(v float64) float64 {
+	step := niceNumber(v/10, false)
+	return math.Ceil(v/step) * step
+}
+
+// formatTick formats milliseconds as seconds when the step allows it.
+func formatTick(ms, step float64) string {
+	if step >= 1000 {
+		return formatNumber(ms/1000, step/1000) + "s"
+	}
+	return formatNumber(ms, step) + "ms"
+}
+
+func formatValue(ms, scale float64) string {
+	if scale >= 1000 {
+		return fmt.Sprintf("%.2fs", ms/1000)
+	}
+	return fmt.Sprintf("%.2fms", ms)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+func TestNiceNumber(t *testing.T) {
+	tests := []struct {
+		span  float64
+		round bool
+		want  float64
+	}{
+		{0, false, 1},
+		{-3, true, 1},
+		{1, false, 1},
+		{1.2, true, 1},
+		{1.2, false, 2},
+		{2.5, true, 2},
+		{3.4, false, 5},
+		{7.3, true, 10},
+		{120, true, 100},
+		{340, false, 500},
+		{0.034, false, 0.05},
+	}
+	for _, test := range tests {
+		if got := niceNumber(test.span, test.round); !near(got, test.want) {
+			t.Errorf("niceNumber(%v, %v) = %v, expected %v", test.span, test.round, got, test.want)
+		}
+	}
+}
+
+func TestNiceCeil(t *testing.T) {
+	tests := []struct{ v, want float64 }{
+		{0, 0},
+		{1, 1},
+		{7.3, 8},
+		{73, 80},
+		{100, 100},
+		{1234, 1400},
+		{0.42, 0.45},
+	}
+	for _, test := range tests {
+		if got := niceCeil(test.v); !near(got, test.want) {
+			t.Errorf("niceCeil(%v) = %v, expected %v", test.v, got, test.want)
+		}
+	}
+}
+
+func TestMirrorZero(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "zero.svg")
+
+	// the first side is missing and the second side is all zero
+	zero := measure.Measurement{Entry: measure.Entry{Dataset: "sg", Approach: "baseline"}}
+	lines := []Line{{Name: "baseline", Measurements: []measure.Measurement{{}, zero}}}
+	if err := Mirror(filename, []Side{{Title: "old"}, {Title: "new"}}, lines); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("NaN")) || bytes.Contains(data, []byte("Inf")) {
+		t.Error("svg contains invalid coordinates")
+	}
+}
+
+func near(a, b float64) bool {
+	d := a - b
+	return -1e-9 < d && d < 1e-9
+}
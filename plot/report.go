@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+// Report bundles result files into a single static HTML page
+// with tables, charts, metadata and deltas against a baseline run.
+func Report(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	output := flags.String("o", "report.html", "output file")
+	title := flags.String("title", "Benchmark report", "page title")
+	baseline := flags.String("baseline", "", "result file to compute deltas against")
+	threshold := flags.Float64("threshold", 0.05, "ratio considered a change")
+	alpha := flags.Float64("alpha", 0.05, "significance level")
+	confidence := flags.Float64("confidence", 0.95, "confidence interval for speedup")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: plot report [-o report.html] [-baseline old.txt] results.txt...")
+	}
+
+	var base *measure.Result
+	if *baseline != "" {
+		var err error
+		base, err = measure.ParseFile(*baseline)
+		if err != nil {
+			return fmt.Errorf("%v: %w", *baseline, err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	page := reportPage{
+		Title:    *title,
+		Baseline: *baseline,
+		Created:  time.Now().UTC().Truncate(time.Second),
+	}
+	for _, file := range flags.Args() {
+		result, err := measure.ParseFile(file)
+		if err != nil {
+			return fmt.Errorf("%v: %w", file, err)
+		}
+
+		run := reportRun{Name: file, Result: result}
+		for _, m := range result.Measurements {
+			row := reportRow{Measurement: m}
+			if base != nil {
+				old := base.Measurements.Entry(m.Entry)
+				if old.Approach != "" {
+					c := measure.Compare(old, m, *confidence)
+					significant := c.P < *alpha || len(old.Timings) == 0 || len(m.Timings) == 0
+					row.Comparison = &c
+					switch {
+					case significant && c.Speedup < 1-*threshold:
+						row.Verdict = "slower"
+					case significant && c.Speedup > 1+*threshold:
+						row.Verdict = "faster"
+					}
+				}
+			}
+			run.Rows = append(run.Rows, row)
+		}
+
+		run.Charts, err = reportCharts(dir, file, result)
+		if err != nil {
+			return err
+		}
+		page.Runs = append(page.Runs, run)
+	}
+
+	var out bytes.Buffer
+	if err := reportTemplate.Execute(&out, page); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*output, out.Bytes(), 0644)
+}
+
+type reportPage struct {
+	Title    string
+	Baseline string
+	Created  time.Time
+	Runs     []reportRun
+}
+
+type reportRun struct {
+	Name   string
+	Result *measure.Result
+	Rows   []reportRow
+	Charts []reportChart
+}
+
+type reportRow struct {
+	measure.Measurement
+	Comparison *measure.Comparison
+	Verdict    string
+}
+
+type reportChart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// reportCharts renders the charts for a result file using the figure types
+// that make sense for the data and returns them for inlining.
+func reportCharts(dir, file string, result *measure.Result) ([]reportChart, error) {
+	var datasets []string
+	seen := map[string]bool{}
+	for _, m := range result.Measurements {
+		if !seen[m.Dataset] {
+			seen[m.Dataset] = true
+			datasets = append(datasets, m.Dataset)
+		}
+	}
+
+	var charts []reportChart
+	render := func(title string, input Input, figure Figure) error {
+		figure.Output = filepath.Join(dir, fmt.Sprintf("chart-%d.svg", len(charts)))
+		config := &Config{Inputs: []Input{input}}
+		if err := config.Load(); err != nil {
+			return err
+		}
+		if err := config.render(&figure); err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(figure.Output)
+		if err != nil {
+			return err
+		}
+		charts = append(charts, reportChart{Title: title, SVG: inlineSVG(data)})
+		return nil
+	}
+
+	for _, dataset := range datasets {
+		xs := result.Measurements.Dataset(dataset)
+		input := Input{File: file, Dataset: dataset}
+
+		if err := render(dataset, input, Figure{}); err != nil {
+			return nil, err
+		}
+		for _, m := range xs {
+			if len(m.Timings) > 0 {
+				if err := render(dataset+" distribution", input, Figure{Type: "box"}); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if xs.A("baseline").Approach != "" && len(xs) > 1 {
+			if err := render(dataset+" speedup", input, Figure{Type: "speedup"}); err != nil {
+				return nil, err
+			}
+		}
+
+		procs := map[int]bool{}
+		for _, m := range xs {
+			if m.Procs > 0 {
+				procs[m.Procs] = true
+			}
+		}
+		if len(procs) > 1 {
+			if err := render(dataset+" goroutines", input, Figure{Type: "procs"}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(datasets) > 1 {
+		var approaches []Approach
+		seen := map[string]bool{}
+		for _, m := range result.Measurements {
+			if !seen[m.Approach] {
+				seen[m.Approach] = true
+				approaches = append(approaches, Approach{Label: m.Approach})
+			}
+		}
+		if err := render("datasets", Input{File: file}, Figure{Type: "heatmap", Approaches: approaches}); err != nil {
+			return nil, err
+		}
+	}
+
+	return charts, nil
+}
+
+// inlineSVG strips the xml declaration and doctype so the svg can be embedded in html.
+func inlineSVG(data []byte) template.HTML {
+	s := string(data)
+	if i := strings.Index(s, "<svg"); i >= 0 {
+		s = s[i:]
+	}
+	return template.HTML(s)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms":    func(v float64) string { return fmt.Sprintf("%.3f", v) },
+	"ratio": func(v float64) string { return fmt.Sprintf("%.2fx", v) },
+	"p":     func(v float64) string { return fmt.Sprintf("%.3f", v) },
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: 2px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num { text-align: right; font-family: monospace; }
+table.sortable th { cursor: pointer; background: #f4f4f4; }
+table.sortable th:after { content: " \2195"; color: #aaa; }
+tr.faster td { background: #e6f5e6; }
+tr.slower td { background: #fbe3e3; }
+.charts { display: flex; flex-wrap: wrap; gap: 1em; }
+.chart { border: 1px solid #ddd; padding: 0.5em; }
+.chart svg { max-width: 100%; height: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Created {{time .Created}}{{if .Baseline}}, deltas are against <code>{{.Baseline}}</code>{{end}}.</p>
+{{range .Runs}}
+<h2>{{.Name}}</h2>
+{{with .Result.Environment}}
+<table>
+	<tr><th>host</th><td>{{.Host}}</td></tr>
+	<tr><th>platform</th><td>{{.OS}}/{{.Arch}}</td></tr>
+	<tr><th>cpu</th><td>{{.CPU}}{{range .Caches}}, {{.}}{{end}}</td></tr>
+	<tr><th>cpus</th><td>{{.NumCPU}} (GOMAXPROCS {{.GOMAXPROCS}})</td></tr>
+	{{if .Affinity}}<tr><th>affinity</th><td>{{.Affinity}}</td></tr>{{end}}
+	<tr><th>go</th><td>{{.GoVersion}}</td></tr>
+	<tr><th>commit</th><td><code>{{.Commit}}</code></td></tr>
+	<tr><th>time</th><td>{{time .Time}}</td></tr>
+	<tr><th>args</th><td><code>{{range .Args}}{{.}} {{end}}</code></td></tr>
+	<tr><th>flags</th><td><code>{{range $k, $v := .Flags}}-{{$k}}={{$v}} {{end}}</code></td></tr>
+</table>
+{{end}}
+{{if .Result.Datasets}}
+<table class="sortable">
+	<thead><tr><th>dataset</th><th>file</th><th>nodes</th><th>edges</th><th>sha256</th></tr></thead>
+	<tbody>
+	{{range .Result.Datasets}}<tr><td>{{.Name}}</td><td>{{.File}}</td><td class="num">{{.Nodes}}</td><td class="num">{{.Edges}}</td><td><code>{{.Checksum}}</code></td></tr>
+	{{end}}
+	</tbody>
+</table>
+{{end}}
+<table class="sortable">
+	<thead><tr>
+		<th>dataset</th><th>approach</th><th>procs</th><th>n</th>
+		<th>median ms</th><th>mean ms</th><th>stdev ms</th><th>min ms</th><th>max ms</th>
+		{{if $.Baseline}}<th>baseline ms</th><th>speedup</th><th>low</th><th>high</th><th>p</th>{{end}}
+	</tr></thead>
+	<tbody>
+	{{range .Rows}}<tr class="{{.Verdict}}">
+		<td>{{.Dataset}}</td><td>{{.Approach}}</td><td class="num">{{.Procs}}</td><td class="num">{{len .Timings}}</td>
+		<td class="num">{{ms .Median}}</td><td class="num">{{ms .Average}}</td><td class="num">{{ms .Stdev}}</td><td class="num">{{ms .Min}}</td><td class="num">{{ms .Max}}</td>
+		{{if $.Baseline}}{{with .Comparison}}<td class="num">{{ms .Old.Median}}</td><td class="num">{{ratio .Speedup}}</td><td class="num">{{ratio .Low}}</td><td class="num">{{ratio .High}}</td><td class="num">{{p .P}}</td>{{else}}<td></td><td></td><td></td><td></td><td></td>{{end}}{{end}}
+	</tr>
+	{{end}}
+	</tbody>
+</table>
+<div class="charts">
+{{range .Charts}}<div class="chart"><h3>{{.Title}}</h3>{{.SVG}}</div>
+{{end}}
+</div>
+{{end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function(table) {
+	table.querySelectorAll("th").forEach(function(th, column) {
+		var ascending = true;
+		th.addEventListener("click", function() {
+			var body = table.tBodies[0];
+			var rows = Array.prototype.slice.call(body.rows);
+			var value = function(row) {
+				var text = row.cells[column].textContent.trim();
+				var number = parseFloat(text);
+				return isNaN(number) ? text : number;
+			};
+			rows.sort(function(a, b) {
+				var x = value(a), y = value(b);
+				var r = x < y ? -1 : x > y ? 1 : 0;
+				return ascending ? r : -r;
+			});
+			ascending = !ascending;
+			rows.forEach(function(row) { body.appendChild(row); });
+		});
+	});
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+	"github.com/egonelbre/a-tale-of-bfs/variants"
+)
+
+// Scaling plots speedup and parallel efficiency of parallel approaches
+// compared to the fastest sequential approach. Only approaches registered as
+// sequential and not experimental are used as the baseline, e.g. "parallel"
+// runs without a procs count but uses several goroutines.
+func Scaling(args []string) error {
+	flags := flag.NewFlagSet("scaling", flag.ExitOnError)
+	prefix := flags.String("o", "scaling", "output file prefix")
+	only := flags.String("dataset", "", "plot only this dataset")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: plot scaling [-o prefix] [-dataset name] results.txt")
+	}
+
+	result, err := measure.ParseFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var datasets []string
+	seen := map[string]bool{}
+	for _, m := range result.Measurements {
+		if !seen[m.Dataset] && (*only == "" || *only == m.Dataset) {
+			seen[m.Dataset] = true
+			datasets = append(datasets, m.Dataset)
+		}
+	}
+
+	for _, dataset := range datasets {
+		xs := result.Measurements.Dataset(dataset)
+
+		best := measure.Measurement{Median: math.Inf(1)}
+		byVariant := map[string]measure.Measurements{}
+		var names []string
+		for _, m := range xs {
+			if m.Procs == 0 {
+				if sequential(m.Approach) && m.Median < best.Median {
+					best = m
+				}
+				continue
+			}
+			name := m.Variant()
+			if _, ok := byVariant[name]; !ok {
+				names = append(names, name)
+			}
+			byVariant[name] = append(byVariant[name], m)
+		}
+		if math.IsInf(best.Median, 1) || len(names) == 0 {
+			return fmt.Errorf("%v: need sequential and parallel measurements", dataset)
+		}
+
+		maxProcs := 0
+		var speedups, efficiencies []Series
+		for _, name := range names {
+			ms := byVariant[name]
+			sort.Slice(ms, func(i, k int) bool { return ms[i].Procs < ms[k].Procs })
+
+			speedup := Series{Name: name}
+			efficiency := Series{Name: name}
+			for _, m := range ms {
+				s := best.Median / m.Median
+				speedup.X = append(speedup.X, float64(m.Procs))
+				speedup.Y = append(speedup.Y, s)
+				efficiency.X = append(efficiency.X, float64(m.Procs))
+				efficiency.Y = append(efficiency.Y, s/float64(m.Procs))
+				if m.Procs > maxProcs {
+					maxProcs = m.Procs
+				}
+			}
+			speedups = append(speedups, speedup)
+			efficiencies = append(efficiencies, efficiency)
+		}
+
+		ideal := Series{Name: "ideal", X: []float64{0, float64(maxProcs)}, Y: []float64{0, float64(maxProcs)}}
+		title := fmt.Sprintf("%v | vs fastest sequential %v", dataset, best.Approach)
+		err := LineChart(fmt.Sprintf("%v-%v-speedup.svg", *prefix, dataset),
+			title, "goroutines", "speedup", &ideal, speedups...)
+		if err != nil {
+			return err
+		}
+
+		perfect := Series{Name: "ideal", X: []float64{0, float64(maxProcs)}, Y: []float64{1, 1}}
+		err = LineChart(fmt.Sprintf("%v-%v-efficiency.svg", *prefix, dataset),
+			title, "goroutines", "efficiency", &perfect, efficiencies...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sequential reports whether approach is a single threaded baseline.
+func sequential(approach string) bool {
+	v, ok := variants.Lookup(approach)
+	return ok && v.Has(variants.Sequential) && !v.Has(variants.Experimental)
+}
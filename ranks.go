@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/egonelbre/a-tale-of-bfs/distributed"
+	"github.com/egonelbre/exp/qpc"
+)
+
+var (
+	ranks    = flag.Int("ranks", 0, "run distributed search with N local processes over TCP")
+	rank     = flag.Int("rank", 0, "rank of this process (set by -ranks)")
+	peers    = flag.String("peers", "", "comma separated addresses of ranks (set by -ranks)")
+	listenFD = flag.Int("listen-fd", 0, "inherited listener of this rank (set by -ranks)")
+)
+
+// RunRanks benchmarks distributed search, where each rank is a separate process.
+// The first process launches the other ranks and reports the results.
+func RunRanks(datasets []Dataset) error {
+	var t distributed.Transport
+	var err error
+	switch {
+	case *peers == "":
+		var wait func()
+		t, wait, err = launchRanks(datasets)
+		defer wait()
+	case *listenFD > 0:
+		var ln net.Listener
+		ln, err = net.FileListener(os.NewFile(uintptr(*listenFD), "listener"))
+		if err == nil {
+			t, err = distributed.ConnectTCP(*rank, ln, strings.Split(*peers, ","))
+		}
+	default:
+		t, err = distributed.DialTCP(*rank, strings.Split(*peers, ","))
+	}
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	name := fmt.Sprintf("distributed tcp %dx", *ranks)
+
+	var out *Output
+	if *rank == 0 {
+		out, err = NewOutput(os.Stdout, datasets)
+		if err != nil {
+			return err
+		}
+	}
+	for _, dataset := range datasets {
+		g := dataset.Graph
+		oracle := NewOracle(g)
+
+		var all []float64
+		var throughput Throughput
+		for _, source := range dataset.Sources {
+			timings := []float64{}
+			levels := make([]int, g.Order())
+			for k := 0; k < *N; k++ {
+				levels = make([]int, g.Order())
+				start := qpc.Now()
+				if err := distributed.BreadthFirst(g, source, levels, t); err != nil {
+					return err
+				}
+				stop := qpc.Now()
+				timings = append(timings, stop.Sub(start).Duration().Seconds())
+			}
+
+			if err := distributed.Gather(levels, t); err != nil {
+				return err
+			}
+			if *rank != 0 {
+				continue
+			}
+
+			if *verify {
+				if err := oracle.Check(source, levels); err != nil {
+					fmt.Fprintln(os.Stderr, "Invalid ", name, "on", dataset.Name, "from", source, err)
+				}
+			}
+
+			all = append(all, timings...)
+			throughput.Add(Traversed(g, levels), timings)
+		}
+		if *rank != 0 {
+			continue
+		}
+
+		stats := Stats(all)
+		fmt.Fprint(os.Stderr, "  > ", name, "\t")
+		fmt.Fprintf(os.Stderr, "%v\t%v\n", stats, throughput.String())
+		out.Add(Measure(dataset, name, *ranks, all, &throughput))
+	}
+
+	if out != nil {
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+
+	return t.Close()
+}
+
+// launchRanks starts the other ranks, each inherits its already open listener
+// so that the addresses can't be taken before the ranks start.
+// wait must be called when done.
+func launchRanks(datasets []Dataset) (t distributed.Transport, wait func(), err error) {
+	var listeners []net.Listener
+	var cmds []*exec.Cmd
+	defer func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}()
+	wait = func() {
+		for _, cmd := range cmds {
+			cmd.Wait()
+		}
+	}
+
+	var addrs []string
+	for r := 0; r < *ranks; r++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, wait, err
+		}
+		listeners = append(listeners, ln)
+		addrs = append(addrs, ln.Addr().String())
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, wait, err
+	}
+
+	var sums []string
+	for _, dataset := range datasets {
+		sums = append(sums, dataset.Info.Checksum)
+	}
+
+	for r := 1; r < *ranks; r++ {
+		f, err := listeners[r].(*net.TCPListener).File()
+		if err != nil {
+			return nil, wait, err
+		}
+
+		args := []string{
+			"-ranks", strconv.Itoa(*ranks),
+			"-rank", strconv.Itoa(r),
+			"-peers", strings.Join(addrs, ","),
+			"-listen-fd", "3",
+			"-N", strconv.Itoa(*N),
+			"-source", strconv.Itoa(*source),
+			"-sources", strconv.Itoa(*sources),
+			"-seed", strconv.FormatInt(*seed, 10),
+			"-verify=" + strconv.FormatBool(*verify),
+			"-mmap=" + strconv.FormatBool(*mmapped),
+			"-format", *format,
+			"-manifest", *manifest,
+			"-checksums", strings.Join(sums, ","),
+		}
+		cmd := exec.Command(exe, append(args, flag.Args()...)...)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		cmd.ExtraFiles = []*os.File{f}
+		err = cmd.Start()
+		f.Close()
+		if err != nil {
+			return nil, wait, err
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	ln := listeners[0]
+	listeners = listeners[1:]
+	t, err = distributed.ConnectTCP(0, ln, addrs)
+	return t, wait, err
+}
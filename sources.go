@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// PickSources picks n distinct non-isolated nodes uniformly at random,
+// it fails when every node is isolated.
+func PickSources(g *graph.Graph, n int, seed int64) ([]graph.Node, error) {
+	degree := func(node graph.Node) uint64 { return g.Span[node+1] - g.Span[node] }
+
+	connected := 0
+	for node := 0; node < g.Order(); node++ {
+		if degree(graph.Node(node)) > 0 {
+			connected++
+		}
+	}
+
+	if connected == 0 {
+		return nil, errors.New("no node has an edge to pick sources from")
+	}
+
+	var sources []graph.Node
+	if connected <= n {
+		for node := 0; node < g.Order(); node++ {
+			if degree(graph.Node(node)) > 0 {
+				sources = append(sources, graph.Node(node))
+			}
+		}
+		return sources, nil
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	picked := map[graph.Node]bool{}
+	for len(sources) < n {
+		node := graph.Node(rng.Intn(g.Order()))
+		if degree(node) == 0 || picked[node] {
+			continue
+		}
+		picked[node] = true
+		sources = append(sources, node)
+	}
+	return sources, nil
+}
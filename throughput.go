@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// Traversal describes the part of the graph visited by a search.
+type Traversal struct {
+	Edges int64
+	Nodes int64
+}
+
+// Traversed counts the visited nodes and their edges.
+func Traversed(g *graph.Graph, levels []int) Traversal {
+	var t Traversal
+	for node, level := range levels {
+		if level > 0 {
+			t.Nodes++
+			t.Edges += int64(g.Span[node+1] - g.Span[node])
+		}
+	}
+	return t
+}
+
+// AdjacencyBytes is the amount of adjacency data read,
+// each visited node reads its span and neighbors.
+func (t Traversal) AdjacencyBytes() int64 {
+	return t.Nodes*2*8 + t.Edges*graph.NodeSize
+}
+
+// Throughput accumulates traversal rates over runs,
+// rates are averaged with harmonic mean as in Graph500.
+type Throughput struct {
+	Runs  int
+	Total Traversal
+
+	inverseEdges float64
+	inverseNodes float64
+}
+
+func (tp *Throughput) Add(t Traversal, timings []float64) {
+	for _, seconds := range timings {
+		tp.Runs++
+		tp.Total.Edges += t.Edges
+		tp.Total.Nodes += t.Nodes
+		tp.inverseEdges += seconds / float64(t.Edges)
+		tp.inverseNodes += seconds / float64(t.Nodes)
+	}
+}
+
+// TEPS returns traversed edges per second.
+func (tp *Throughput) TEPS() float64 { return float64(tp.Runs) / tp.inverseEdges }
+
+// NodesPerSecond returns visited nodes per second.
+func (tp *Throughput) NodesPerSecond() float64 { return float64(tp.Runs) / tp.inverseNodes }
+
+// PerRun returns the average traversal of a single run.
+func (tp *Throughput) PerRun() Traversal {
+	if tp.Runs == 0 {
+		return Traversal{}
+	}
+	return Traversal{
+		Edges: tp.Total.Edges / int64(tp.Runs),
+		Nodes: tp.Total.Nodes / int64(tp.Runs),
+	}
+}
+
+func (tp *Throughput) String() string {
+	run := tp.PerRun()
+	return fmt.Sprintf("%v\t%.2f Mnodes/s\t%.1f MB/run", FormatTEPS(tp.TEPS()),
+		tp.NodesPerSecond()/1e6, float64(run.AdjacencyBytes())/1e6)
+}
+
+func FormatTEPS(teps float64) string {
+	if teps >= 1e9 {
+		return fmt.Sprintf("%.2f GTEPS", teps/1e9)
+	}
+	return fmt.Sprintf("%.2f MTEPS", teps/1e6)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/trace"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
+)
+
+var (
+	traceFile   = flag.String("trace", "", "write per-level statistics as CSV, requires -tags bfstrace")
+	goTraceFile = flag.String("gotrace", "", "write Go execution trace, levels are annotated with -tags bfstrace")
+
+	traces io.Writer
+)
+
+// StartTracing opens the files for -trace and -gotrace.
+func StartTracing() (stop func(), err error) {
+	var closers []func()
+	stop = func() {
+		for _, close := range closers {
+			close()
+		}
+	}
+
+	if *traceFile != "" {
+		if !tracing.Enabled {
+			return stop, errors.New("-trace requires building with -tags bfstrace")
+		}
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			return stop, err
+		}
+		closers = append(closers, func() { f.Close() })
+		fmt.Fprintln(f, tracing.CSVHeader)
+		traces = f
+	}
+
+	if *goTraceFile != "" {
+		f, err := os.Create(*goTraceFile)
+		if err != nil {
+			return stop, err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		closers = append(closers, func() { trace.Stop(); f.Close() })
+	}
+
+	return stop, nil
+}
+
+// TraceRun runs a single search recording per-level statistics,
+// approaches without instrumentation produce no rows.
+func TraceRun(name string, g *graph.Graph, source graph.Node, iterate IterateFn) error {
+	if traces == nil {
+		return nil
+	}
+
+	tracing.Start(name)
+	iterate(g, source, make([]int, g.Order()))
+	return tracing.Stop().WriteCSV(traces)
+}
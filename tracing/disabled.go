@@ -0,0 +1,20 @@
+//go:build !bfstrace
+// +build !bfstrace
+
+package tracing
+
+const Enabled = false
+
+func Start(name string)                              {}
+func Stop() *Trace                                   { return nil }
+func BeginLevel(level, frontier, workers int) *Level { return nil }
+func Count(worker int, edges, discovered int64)      {}
+func CASFailed()                                     {}
+
+func (level *Level) Count(worker int, edges, discovered int64) {}
+
+type Span struct{}
+
+func Begin(worker int, phase Phase) Span                { return Span{} }
+func (level *Level) Begin(worker int, phase Phase) Span { return Span{} }
+func (span Span) End()                                  {}
@@ -0,0 +1,130 @@
+//go:build bfstrace
+// +build bfstrace
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+const Enabled = true
+
+var (
+	active *Trace
+	// current is the *Level used by the global hooks, it's atomic
+	// because workers may call CASFailed while another begins a level.
+	current atomic.Value
+
+	ctx  = context.Background()
+	task *trace.Task
+)
+
+// Start starts recording searches, name is used for the execution trace task.
+func Start(name string) {
+	active = &Trace{Name: name}
+	current.Store((*Level)(nil))
+	ctx, task = trace.NewTask(context.Background(), name)
+}
+
+// Stop stops recording and returns the recorded trace.
+func Stop() *Trace {
+	if task != nil {
+		task.End()
+	}
+	t := active
+	active, task = nil, nil
+	current.Store((*Level)(nil))
+	ctx = context.Background()
+	return t
+}
+
+func currentLevel() *Level {
+	level, _ := current.Load().(*Level)
+	return level
+}
+
+// BeginLevel starts a new level and returns it, nil when not recording.
+// It must not be called concurrently with other BeginLevel calls,
+// workers running concurrently should use the returned level for their hooks.
+func BeginLevel(level, frontier, workers int) *Level {
+	if active == nil {
+		return nil
+	}
+	l := &Level{
+		Level:    level,
+		Frontier: frontier,
+		Workers:  make([]Worker, workers),
+	}
+	active.Levels = append(active.Levels, l)
+	current.Store(l)
+	trace.Logf(ctx, "level", "%d frontier=%d", level, frontier)
+	return l
+}
+
+// Count adds scanned edges and discovered nodes to the worker of the current level.
+func Count(worker int, edges, discovered int64) { currentLevel().Count(worker, edges, discovered) }
+
+// Count adds scanned edges and discovered nodes to the worker.
+func (level *Level) Count(worker int, edges, discovered int64) {
+	if level == nil {
+		return
+	}
+	w := &level.Workers[worker]
+	w.Edges += edges
+	w.Discovered += discovered
+}
+
+// CASFailed counts a failed compare-and-swap in the current level.
+func CASFailed() {
+	if level := currentLevel(); level != nil {
+		atomic.AddInt64(&level.CASFailures, 1)
+	}
+}
+
+// Span measures a single phase of a worker.
+type Span struct {
+	level  *Level
+	worker int
+	phase  Phase
+	start  time.Time
+	region *trace.Region
+}
+
+// Begin starts measuring a phase in the current level.
+func Begin(worker int, phase Phase) Span { return currentLevel().Begin(worker, phase) }
+
+// Begin starts measuring a phase, the span must be ended on the same goroutine.
+func (level *Level) Begin(worker int, phase Phase) Span {
+	if level == nil {
+		return Span{}
+	}
+	if worker == AnyWorker {
+		worker = int(atomic.AddInt32(&level.claimed[phase], 1) - 1)
+	}
+	span := Span{
+		level:  level,
+		worker: worker,
+		phase:  phase,
+		start:  time.Now(),
+	}
+	if trace.IsEnabled() {
+		span.region = trace.StartRegion(ctx, fmt.Sprintf("level %d %v", level.Level, phase))
+	}
+	return span
+}
+
+func (span Span) End() {
+	if span.level == nil {
+		return
+	}
+	if span.region != nil {
+		span.region.End()
+	}
+	if span.worker < len(span.level.Workers) {
+		span.level.Workers[span.worker].Phases[span.phase] += time.Since(span.start)
+	}
+}
@@ -0,0 +1,85 @@
+// Package tracing records per-level statistics and phase timings of searches.
+//
+// Recording is only compiled in with the bfstrace build tag, otherwise
+// Enabled is false and the hooks are removed by the compiler.
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+type Phase int
+
+const (
+	Expand Phase = iota
+	Sort
+	Wait
+	PhaseCount
+)
+
+func (phase Phase) String() string {
+	switch phase {
+	case Expand:
+		return "expand"
+	case Sort:
+		return "sort"
+	case Wait:
+		return "wait"
+	}
+	return "unknown"
+}
+
+// AnyWorker can be used when the worker index is not known,
+// each Begin then claims the next unused worker for the phase.
+const AnyWorker = -1
+
+// Worker contains statistics of a single worker during a level.
+type Worker struct {
+	Phases     [PhaseCount]time.Duration
+	Edges      int64
+	Discovered int64
+}
+
+// Level contains statistics of processing a single frontier,
+// Frontier includes sentinel padding of block based frontiers.
+type Level struct {
+	Level       int
+	Frontier    int
+	CASFailures int64
+	Workers     []Worker
+
+	claimed [PhaseCount]int32
+}
+
+// Trace contains all levels of a single search.
+type Trace struct {
+	Name   string
+	Levels []*Level
+}
+
+const CSVHeader = "name,level,worker,frontier,edges,discovered,casfailures,expand_ns,sort_ns,wait_ns"
+
+// WriteCSV writes a row for each worker in each level,
+// CAS failures are only counted per level and reported in the first worker.
+func (trace *Trace) WriteCSV(w io.Writer) error {
+	for _, level := range trace.Levels {
+		for i, worker := range level.Workers {
+			casFailures := int64(0)
+			if i == 0 {
+				casFailures = level.CASFailures
+			}
+			_, err := fmt.Fprintf(w, "%q,%d,%d,%d,%d,%d,%d,%d,%d,%d\n",
+				trace.Name, level.Level, i, level.Frontier,
+				worker.Edges, worker.Discovered, casFailures,
+				worker.Phases[Expand].Nanoseconds(),
+				worker.Phases[Sort].Nanoseconds(),
+				worker.Phases[Wait].Nanoseconds())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
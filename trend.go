@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/egonelbre/a-tale-of-bfs/measure"
+)
+
+// Trend writes the performance history of each approach from a -history file
+// to w and marks change points, it returns 2 on errors.
+func Trend(w io.Writer, args []string) int {
+	flags := flag.NewFlagSet("trend", flag.ExitOnError)
+	history := flags.String("history", "history.jsonl", "history file written with -history")
+	host := flags.String("host", "", "only show runs from this host")
+	dataset := flags.String("dataset", "", "only show this dataset")
+	run := flags.String("run", "", "filter approaches")
+	threshold := flags.Float64("threshold", 0.05, "median change ratio considered a change point")
+	alpha := flags.Float64("alpha", 0.05, "significance level")
+	min := flags.Int("min", 3, "minimum number of runs between change points")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: a-tale-of-bfs trend [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	results, err := measure.ReadHistory(*history)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	type key struct {
+		Host string
+		measure.Entry
+	}
+	type point struct {
+		Env         *measure.Environment
+		Measurement measure.Measurement
+	}
+
+	var keys []key
+	series := map[key][]point{}
+	for i := range results {
+		result := &results[i]
+		env := &result.Environment
+		if *host != "" && env.Host != *host {
+			continue
+		}
+		for _, m := range result.Measurements {
+			if (*dataset != "" && m.Dataset != *dataset) || !filter.MatchString(m.Approach) {
+				continue
+			}
+			k := key{env.Host, m.Entry}
+			if _, ok := series[k]; !ok {
+				keys = append(keys, k)
+			}
+			series[k] = append(series[k], point{env, m})
+		}
+	}
+	sort.SliceStable(keys, func(i, k int) bool {
+		if keys[i].Host != keys[k].Host {
+			return keys[i].Host < keys[k].Host
+		}
+		return keys[i].Dataset < keys[k].Dataset
+	})
+
+	changes := 0
+	for _, k := range keys {
+		points := series[k]
+		medians := make([]float64, len(points))
+		for i, p := range points {
+			medians[i] = p.Measurement.Median
+		}
+
+		// marks is the change of segment medians around each change point
+		marks := map[int]string{}
+		bounds := append(append([]int{0}, measure.ChangePoints(medians, *threshold, *alpha, *min)...), len(medians))
+		for i := 1; i+1 < len(bounds); i++ {
+			before, after := median(medians[bounds[i-1]:bounds[i]]), median(medians[bounds[i]:bounds[i+1]])
+			marks[bounds[i]] = "from 0"
+			if before != 0 {
+				marks[bounds[i]] = fmt.Sprintf("%+.1f%%", (after/before-1)*100)
+			}
+		}
+		changes += len(marks)
+
+		fmt.Fprintf(w, "# %v\t%v\t%v\n", k.Host, k.Dataset, k.Approach)
+		fmt.Fprintln(w, "time\tcommit\tmedian\tstdev\tchange")
+		for i, p := range points {
+			commit := strings.TrimSuffix(p.Env.Commit, "-dirty")
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+			if strings.HasSuffix(p.Env.Commit, "-dirty") {
+				commit += "-dirty"
+			}
+			fmt.Fprintf(w, "%v\t%v\t%.2f\t%.2f\t%v\n",
+				p.Env.Time.Format("2006-01-02 15:04"), commit,
+				p.Measurement.Median, p.Measurement.Stdev, marks[i])
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d runs, %d change points\n", len(results), changes)
+	return 0
+}
+
+func median(xs []float64) float64 {
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-host", "lab", "-dataset", "sg"}, `# lab	sg	baseline
+time	commit	median	stdev	change
+2020-02-01 10:00	a11ce00	1.00	0.01	
+2020-02-02 10:00	a11ce01	1.01	0.01	
+2020-02-03 10:00	a11ce02	0.99	0.01	
+2020-02-04 10:00	a11ce03	1.00	0.01	
+2020-02-05 10:00	a11ce04	1.50	0.01	+50.0%
+2020-02-06 10:00	a11ce05	1.51	0.01	
+2020-02-07 10:00	a11ce06	1.49	0.01	
+2020-02-08 10:00	0123456789ab-dirty	1.50	0.01	
+
+`},
+		// the median shifts from zero
+		{[]string{"-host", "ci"}, `# ci	sg	baseline
+time	commit	median	stdev	change
+2020-03-01 10:00	c1c1c00	0.00	0.00	
+2020-03-02 10:00	c1c1c01	0.00	0.00	
+2020-03-03 10:00	c1c1c02	0.00	0.00	
+2020-03-04 10:00	c1c1c03	0.00	0.00	
+2020-03-05 10:00	c1c1c04	2.00	0.00	from 0
+2020-03-06 10:00	c1c1c05	2.00	0.00	
+2020-03-07 10:00	c1c1c06	2.00	0.00	
+2020-03-08 10:00	c1c1c07	2.00	0.00	
+
+`},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		args := append([]string{"-history", "testdata/history.jsonl"}, test.args...)
+		if code := Trend(&out, args); code != 0 {
+			t.Fatalf("%v: exit code %d", test.args, code)
+		}
+		if got := out.String(); got != test.expected {
+			t.Errorf("%v: got\n%s\nexpected\n%s", test.args, got, test.expected)
+		}
+	}
+
+	var out bytes.Buffer
+	if code := Trend(&out, []string{"-history", "testdata/history.jsonl", "-dataset", "big"}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if got := strings.Count(out.String(), "# "); got != 1 || !strings.Contains(out.String(), "# lab\tbig\tbaseline") {
+		t.Errorf("-dataset big: got\n%s", out.String())
+	}
+
+	if code := Trend(&out, []string{"-history", "testdata/missing.jsonl"}); code != 2 {
+		t.Errorf("missing history: got exit code %d, expected 2", code)
+	}
+}
@@ -0,0 +1,45 @@
+package variants
+
+import (
+	"reflect"
+	"testing"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/affinity"
+)
+
+// TestAffinity runs the parallel variants with pinned workers and first-touch allocation.
+func TestAffinity(t *testing.T) {
+	topology, err := affinity.Topology(nil)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer affinity.Set(affinity.Policy{Name: "none"})
+
+	for _, spec := range []string{"compact", "scatter"} {
+		p, err := affinity.Parse(spec, topology)
+		if err != nil {
+			t.Fatal(err)
+		}
+		affinity.Set(p)
+
+		for _, tg := range testGraphs() {
+			source := tg.Sources[0]
+			expected := make([]int, tg.Graph.Order())
+			s00_baseline.BreadthFirst(tg.Graph, source, expected)
+
+			for _, v := range All {
+				if v.Parallel == nil {
+					continue
+				}
+				for _, procs := range []int{1, 3, 4} {
+					levels := make([]int, tg.Graph.Order())
+					v.Parallel(tg.Graph, source, levels, procs)
+					if !reflect.DeepEqual(expected, levels) {
+						t.Errorf("%v: %v on %v: levels differ from baseline", spec, v.Label(procs), tg.Name)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package variants
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// fuzzGraph builds an undirected graph where node 0 has fanout neighbors,
+// which fixes the frontier size of the second level, and data encodes
+// additional random edges.
+func fuzzGraph(nodes, fanout int, data []byte) *graph.Graph {
+	var edges []graph.Edge
+	for i := 1; i <= fanout && i < nodes; i++ {
+		edges = append(edges, graph.Edge{From: 0, To: graph.Node(i)})
+	}
+	for ; len(data) >= 4; data = data[4:] {
+		from := int(binary.LittleEndian.Uint16(data[0:])) % nodes
+		to := int(binary.LittleEndian.Uint16(data[2:])) % nodes
+		edges = append(edges, graph.Edge{From: graph.Node(from), To: graph.Node(to)})
+	}
+	return fromEdges(nodes, undirected(edges))
+}
+
+func FuzzParallel(f *testing.F) {
+	chain := make([]byte, 0, 4*300)
+	for i := 0; i < 300; i++ {
+		chain = append(chain, byte(i), byte(i>>8), byte(i+1), byte((i+1)>>8))
+	}
+
+	for _, fanout := range []uint16{1, 255, 256, 257, 511, 512, 513, 1023, 1024, 1025} {
+		f.Add(uint16(fanout+10), fanout, uint8(3), uint16(0), []byte{})
+		f.Add(uint16(2*fanout+1), fanout, uint8(4), uint16(0), chain)
+	}
+	f.Add(uint16(1), uint16(0), uint8(1), uint16(0), []byte{})
+	f.Add(uint16(400), uint16(0), uint8(8), uint16(7), chain)
+
+	f.Fuzz(func(t *testing.T, nodes, fanout uint16, procs uint8, source uint16, data []byte) {
+		n := 1 + int(nodes)%2048
+		p := 1 + int(procs)%8
+		g := fuzzGraph(n, int(fanout), data)
+		s := graph.Node(int(source) % n)
+
+		expected := make([]int, g.Order())
+		s00_baseline.BreadthFirst(g, s, expected)
+
+		for _, v := range All {
+			if v.Parallel == nil {
+				continue
+			}
+			levels := make([]int, g.Order())
+			v.Parallel(g, s, levels, p)
+			if !reflect.DeepEqual(expected, levels) {
+				t.Errorf("%v %dx from %v: levels differ from baseline", v.Name, p, s)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package variants
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Selection chooses variants with a comma separated list of terms,
+// terms starting with "-" exclude variants. A term is a list of
+// conditions separated by "&", which all must match:
+//
+//	@tag      variant has the tag, e.g. @parallel
+//	procs>=4  goroutine count compared with =, !=, <, <=, > or >=,
+//	          sequential variants have 0 procs
+//	pattern   regular expression matched against the label, e.g. "early2 4x"
+//
+// Commas and ampersands inside the parentheses, braces or character classes
+// of a pattern, such as "unroll( 8){1,2}", or escaped with a backslash don't
+// separate terms or conditions. Unknown tags are an error.
+//
+// For example "@parallel&procs=8,-cuckoo". Without include terms all
+// variants are selected. Variants tagged skip are only selected by a term
+// that has a pattern or @skip.
+type Selection struct {
+	include []term
+	exclude []term
+}
+
+type term []condition
+
+type condition struct {
+	tag     string
+	op      string
+	procs   int
+	pattern *regexp.Regexp
+}
+
+var procsCondition = regexp.MustCompile(`^procs\s*(=|!=|<=|>=|<|>)\s*(\d+)$`)
+
+// ParseSelection parses the selection syntax described in Selection.
+func ParseSelection(s string) (*Selection, error) {
+	tags := map[string]bool{}
+	for _, v := range All {
+		for _, tag := range v.Tags {
+			tags[tag] = true
+		}
+	}
+
+	sel := &Selection{}
+	for _, text := range split(s, ',') {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		exclude := strings.HasPrefix(text, "-")
+		text = strings.TrimPrefix(text, "-")
+
+		var t term
+		for _, part := range split(text, '&') {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "":
+				return nil, fmt.Errorf("invalid selection %q: empty condition", text)
+			case strings.HasPrefix(part, "@"):
+				if !tags[part[1:]] {
+					return nil, fmt.Errorf("invalid selection %q: unknown tag %v", text, part)
+				}
+				t = append(t, condition{tag: part[1:]})
+			case procsCondition.MatchString(part):
+				match := procsCondition.FindStringSubmatch(part)
+				procs, _ := strconv.Atoi(match[2])
+				t = append(t, condition{op: match[1], procs: procs})
+			default:
+				rx, err := regexp.Compile(part)
+				if err != nil {
+					return nil, fmt.Errorf("invalid selection %q: %w", part, err)
+				}
+				t = append(t, condition{pattern: rx})
+			}
+		}
+
+		if exclude {
+			sel.exclude = append(sel.exclude, t)
+		} else {
+			sel.include = append(sel.include, t)
+		}
+	}
+	return sel, nil
+}
+
+// split splits s at sep, except where sep is escaped with a backslash
+// or inside the parentheses, braces or a character class of a pattern.
+func split(s string, sep byte) []string {
+	var parts []string
+	start, depth := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			i = classEnd(s, i)
+		case '(', '{':
+			depth++
+		case ')', '}':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// classEnd returns the index of the "]" closing the character class starting at i.
+func classEnd(s string, i int) int {
+	k := i + 1
+	if k < len(s) && s[k] == '^' {
+		k++
+	}
+	// a leading "]" is part of the class
+	if k < len(s) && s[k] == ']' {
+		k++
+	}
+	for ; k < len(s); k++ {
+		switch {
+		case s[k] == '\\':
+			k++
+		case strings.HasPrefix(s[k:], "[:"):
+			if end := strings.Index(s[k:], ":]"); end >= 0 {
+				k += end + 1
+			}
+		case s[k] == ']':
+			return k
+		}
+	}
+	return len(s)
+}
+
+// Match reports whether v running with procs goroutines is selected.
+func (sel *Selection) Match(v Variant, procs int) bool {
+	for _, t := range sel.exclude {
+		if t.match(v, procs) {
+			return false
+		}
+	}
+	if len(sel.include) == 0 {
+		return !v.Has(Skip)
+	}
+	for _, t := range sel.include {
+		if t.match(v, procs) && (!v.Has(Skip) || t.explicit()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t term) match(v Variant, procs int) bool {
+	for _, c := range t {
+		if !c.match(v, procs) {
+			return false
+		}
+	}
+	return true
+}
+
+// explicit reports whether the term names variants rather than only filtering them.
+func (t term) explicit() bool {
+	for _, c := range t {
+		if c.pattern != nil || c.tag == Skip {
+			return true
+		}
+	}
+	return false
+}
+
+func (c condition) match(v Variant, procs int) bool {
+	if v.Parallel == nil {
+		procs = 0
+	}
+	switch {
+	case c.tag != "":
+		return v.Has(c.tag)
+	case c.pattern != nil:
+		return c.pattern.MatchString(v.Label(procs))
+	}
+
+	switch c.op {
+	case "=":
+		return procs == c.procs
+	case "!=":
+		return procs != c.procs
+	case "<":
+		return procs < c.procs
+	case "<=":
+		return procs <= c.procs
+	case ">":
+		return procs > c.procs
+	case ">=":
+		return procs >= c.procs
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package variants
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelection(t *testing.T) {
+	tests := []struct {
+		selection string
+		procs     []int
+		want      []string
+	}{
+		{"^(baseline|ordering)$", nil, []string{"baseline", "ordering"}},
+		{"unroll 8", nil, []string{"unroll 8", "unroll 8 4"}},
+		{"cuckoo", nil, []string{"cuckoo"}},
+		{"@experimental", nil, []string{"external", "distributed 4x", "distributed 8x"}},
+		{"@experimental,@skip", nil, []string{"cuckoo", "parallel", "external", "distributed 4x", "distributed 8x"}},
+		{"@parallel&procs=8,-^(early|parchan|frontier|almost|marking|distributed)", nil, []string{"worker 8x", "busy 8x"}},
+		{"early2&procs>=4", []int{1, 2, 4}, []string{"early2 4x"}},
+		{"early2&procs<4", []int{1, 2, 4}, []string{"early2 1x", "early2 2x"}},
+		{"procs=0&^u", nil, []string{"unroll 4", "unroll 8", "unroll 8 4"}},
+		{"@sequential,-^[a-t]", nil, []string{"unroll 4", "unroll 8", "unroll 8 4"}},
+		{`^unroll( \d){1,2}$`, nil, []string{"unroll 4", "unroll 8", "unroll 8 4"}},
+		{`^[b,o][ar]&procs=0`, nil, []string{"baseline", "ordering"}},
+		{`^(baseline|[&]x),^ordering\,?$`, nil, []string{"baseline", "ordering"}},
+		{`^[]b]ase,^[[:alpha:],]uck`, nil, []string{"baseline", "cuckoo"}},
+	}
+
+	for _, test := range tests {
+		sel, err := ParseSelection(test.selection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		procs := test.procs
+		if procs == nil {
+			procs = []int{4, 8}
+		}
+
+		var got []string
+		for _, v := range All {
+			ps := procs
+			if v.Parallel == nil {
+				ps = []int{0}
+			}
+			for _, p := range ps {
+				if sel.Match(v, p) {
+					got = append(got, v.Label(p))
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, expected %q", test.selection, got, test.want)
+		}
+	}
+}
+
+func TestSelectionDefault(t *testing.T) {
+	sel, err := ParseSelection("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range All {
+		if sel.Match(v, 4) == v.Has(Skip) {
+			t.Errorf("%v: selected %v", v.Name, !v.Has(Skip))
+		}
+	}
+
+	for _, selection := range []string{"early(", "@nosuchtag", "@parallel&@paralel", "early&", "-"} {
+		if _, err := ParseSelection(selection); err == nil {
+			t.Errorf("%q: expected error", selection)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build bfstrace
+// +build bfstrace
+
+package variants
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/tracing"
+)
+
+// TestTrace records searches of the instrumented variants,
+// run it with -race to check that the hooks don't race with the workers:
+//
+//	go test -race -tags bfstrace -run TestTrace ./variants
+func TestTrace(t *testing.T) {
+	g := randomGraph(3, 20000, 8)
+	expected := make([]int, g.Order())
+	s00_baseline.BreadthFirst(g, 0, expected)
+
+	for _, name := range []string{"ordering", "worker", "busy"} {
+		v, ok := Lookup(name)
+		if !ok {
+			t.Fatalf("%v not registered", name)
+		}
+		procs := []int{1}
+		if v.Parallel != nil {
+			procs = []int{1, 2, 4, 8}
+		}
+		for _, p := range procs {
+			for k := 0; k < 10; k++ {
+				tracing.Start(v.Label(p))
+				levels := make([]int, g.Order())
+				v.WithProcs(p)(g, 0, levels)
+				trace := tracing.Stop()
+
+				if !reflect.DeepEqual(expected, levels) {
+					t.Fatalf("%v: levels differ from baseline", v.Label(p))
+				}
+				if len(trace.Levels) == 0 {
+					t.Fatalf("%v: no levels recorded", v.Label(p))
+				}
+				if err := trace.WriteCSV(ioutil.Discard); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package variants lists all the breadth first search implementations.
+package variants
+
+import (
+	"fmt"
+
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	s01_reuse_level "github.com/egonelbre/a-tale-of-bfs/01_reuse_level"
+	s02_sort "github.com/egonelbre/a-tale-of-bfs/02_sort"
+	s03_inline_sort "github.com/egonelbre/a-tale-of-bfs/03_inline_sort"
+	s04_radix_sort "github.com/egonelbre/a-tale-of-bfs/04_radix_sort"
+	s05_lift_level "github.com/egonelbre/a-tale-of-bfs/05_lift_level"
+
+	s06_ordering "github.com/egonelbre/a-tale-of-bfs/06_ordering"
+	s07_fused "github.com/egonelbre/a-tale-of-bfs/07_fused"
+	s07_fused_if "github.com/egonelbre/a-tale-of-bfs/07_fused_if"
+	s08_cuckoo "github.com/egonelbre/a-tale-of-bfs/08_cuckoo"
+
+	s09_unroll_4 "github.com/egonelbre/a-tale-of-bfs/09_unroll_4"
+	s09_unroll_8 "github.com/egonelbre/a-tale-of-bfs/09_unroll_8"
+	s09_unroll_8_4 "github.com/egonelbre/a-tale-of-bfs/09_unroll_8_4"
+
+	s10_parallel "github.com/egonelbre/a-tale-of-bfs/10_parallel"
+	s10_parchan "github.com/egonelbre/a-tale-of-bfs/10_parchan"
+	s11_frontier "github.com/egonelbre/a-tale-of-bfs/11_frontier"
+	s12_almost "github.com/egonelbre/a-tale-of-bfs/12_almost"
+	s13_marking "github.com/egonelbre/a-tale-of-bfs/13_marking"
+
+	s14_early_2 "github.com/egonelbre/a-tale-of-bfs/14_early_2"
+	s14_early_3 "github.com/egonelbre/a-tale-of-bfs/14_early_3"
+	s14_early_4 "github.com/egonelbre/a-tale-of-bfs/14_early_4"
+	s14_early_r "github.com/egonelbre/a-tale-of-bfs/14_early_r"
+
+	s15_worker "github.com/egonelbre/a-tale-of-bfs/15_worker"
+	s16_busy "github.com/egonelbre/a-tale-of-bfs/16_busy"
+	s17_external "github.com/egonelbre/a-tale-of-bfs/17_external"
+
+	"github.com/egonelbre/a-tale-of-bfs/distributed"
+)
+
+type Iterate func(g *graph.Graph, source graph.Node, levels []int)
+type IterateParallel func(g *graph.Graph, source graph.Node, levels []int, procs int)
+
+// Tags describe variants for selection.
+const (
+	Sequential   = "sequential"
+	Parallel     = "parallel"
+	Experimental = "experimental"
+	// Skip marks slow variants, which are only run when selected
+	// explicitly and then benchmarked only once.
+	Skip = "skip"
+)
+
+// Variant is a single search implementation,
+// either Iterate or Parallel is set.
+type Variant struct {
+	Name     string
+	Iterate  Iterate
+	Parallel IterateParallel
+	Tags     []string
+}
+
+// Has reports whether the variant has tag.
+func (v Variant) Has(tag string) bool {
+	for _, t := range v.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Label is the name of the variant running with procs goroutines.
+func (v Variant) Label(procs int) string {
+	if v.Parallel == nil {
+		return v.Name
+	}
+	return fmt.Sprintf("%v %dx", v.Name, procs)
+}
+
+// Lookup finds a registered variant by name.
+func Lookup(name string) (Variant, bool) {
+	for _, v := range All {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// WithProcs returns the search using procs goroutines.
+func (v Variant) WithProcs(procs int) Iterate {
+	if v.Parallel == nil {
+		return v.Iterate
+	}
+	return func(g *graph.Graph, source graph.Node, levels []int) {
+		v.Parallel(g, source, levels, procs)
+	}
+}
+
+var All = []Variant{
+	{Name: "baseline", Iterate: s00_baseline.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "reuse level", Iterate: s01_reuse_level.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "sort", Iterate: s02_sort.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "inline sort", Iterate: s03_inline_sort.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "radix sort", Iterate: s04_radix_sort.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "lift level", Iterate: s05_lift_level.BreadthFirst, Tags: []string{Sequential}},
+
+	{Name: "ordering", Iterate: s06_ordering.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "fused", Iterate: s07_fused.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "fused if", Iterate: s07_fused_if.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "cuckoo", Iterate: s08_cuckoo.BreadthFirst, Tags: []string{Sequential, Experimental, Skip}},
+
+	{Name: "unroll 4", Iterate: s09_unroll_4.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "unroll 8", Iterate: s09_unroll_8.BreadthFirst, Tags: []string{Sequential}},
+	{Name: "unroll 8 4", Iterate: s09_unroll_8_4.BreadthFirst, Tags: []string{Sequential}},
+
+	{Name: "parallel", Iterate: s10_parallel.BreadthFirst, Tags: []string{Parallel, Skip}},
+	{Name: "parchan", Parallel: s10_parchan.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "frontier", Parallel: s11_frontier.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "almost", Parallel: s12_almost.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "marking", Parallel: s13_marking.BreadthFirst, Tags: []string{Parallel}},
+
+	{Name: "early2", Parallel: s14_early_2.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "early3", Parallel: s14_early_3.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "early4", Parallel: s14_early_4.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "earlyR", Parallel: s14_early_r.BreadthFirst, Tags: []string{Parallel}},
+
+	{Name: "worker", Parallel: s15_worker.BreadthFirst, Tags: []string{Parallel}},
+	{Name: "busy", Parallel: s16_busy.BreadthFirst, Tags: []string{Parallel}},
+
+	{Name: "external", Iterate: s17_external.BreadthFirst, Tags: []string{Sequential, Experimental}},
+	{Name: "distributed", Parallel: simulate, Tags: []string{Parallel, Experimental}},
+}
+
+// simulate runs the distributed search in process, where the ranks talk over
+// channels which can't fail, so an error means that the search is broken.
+func simulate(g *graph.Graph, source graph.Node, levels []int, ranks int) {
+	if err := distributed.Simulate(g, source, levels, ranks); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,211 @@
+package variants
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+var dataset = flag.String("dataset", "../data/sg-10k-250k.txt", "graph used for benchmarks")
+
+var procsList = []int{1, 2, 3, 4, 8}
+
+type testGraph struct {
+	Name    string
+	Graph   *graph.Graph
+	Sources []graph.Node
+}
+
+func fromEdges(nodes int, edges []graph.Edge) *graph.Graph {
+	sort.SliceStable(edges, func(i, k int) bool {
+		if edges[i].From == edges[k].From {
+			return edges[i].To < edges[k].To
+		}
+		return edges[i].From < edges[k].From
+	})
+	return graph.FromEdges(nodes, edges)
+}
+
+func undirected(edges []graph.Edge) []graph.Edge {
+	all := make([]graph.Edge, 0, 2*len(edges))
+	for _, e := range edges {
+		all = append(all, e, graph.Edge{From: e.To, To: e.From})
+	}
+	return all
+}
+
+func pathGraph(nodes int) *graph.Graph {
+	var edges []graph.Edge
+	for i := 1; i < nodes; i++ {
+		edges = append(edges, graph.Edge{From: graph.Node(i - 1), To: graph.Node(i)})
+	}
+	return fromEdges(nodes, undirected(edges))
+}
+
+func starGraph(nodes int) *graph.Graph {
+	var edges []graph.Edge
+	for i := 1; i < nodes; i++ {
+		edges = append(edges, graph.Edge{From: 0, To: graph.Node(i)})
+	}
+	return fromEdges(nodes, undirected(edges))
+}
+
+func gridGraph(w, h int) *graph.Graph {
+	var edges []graph.Edge
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := graph.Node(y*w + x)
+			if x+1 < w {
+				edges = append(edges, graph.Edge{From: n, To: n + 1})
+			}
+			if y+1 < h {
+				edges = append(edges, graph.Edge{From: n, To: n + graph.Node(w)})
+			}
+		}
+	}
+	return fromEdges(w*h, undirected(edges))
+}
+
+func randomEdges(rng *rand.Rand, lo, hi, count int) []graph.Edge {
+	edges := make([]graph.Edge, count)
+	for i := range edges {
+		edges[i].From = graph.Node(lo + rng.Intn(hi-lo))
+		edges[i].To = graph.Node(lo + rng.Intn(hi-lo))
+	}
+	return edges
+}
+
+func randomGraph(seed int64, nodes, degree int) *graph.Graph {
+	rng := rand.New(rand.NewSource(seed))
+	return fromEdges(nodes, undirected(randomEdges(rng, 0, nodes, nodes*degree/2)))
+}
+
+func disconnectedGraph(nodes int) *graph.Graph {
+	rng := rand.New(rand.NewSource(2))
+	half := nodes / 2
+	edges := randomEdges(rng, 0, half, half*2)
+	edges = append(edges, randomEdges(rng, half, nodes, half*2)...)
+	return fromEdges(nodes, undirected(edges))
+}
+
+func selfLoopGraph(nodes int) *graph.Graph {
+	var edges []graph.Edge
+	for i := 0; i < nodes; i++ {
+		edges = append(edges, graph.Edge{From: graph.Node(i), To: graph.Node(i)})
+	}
+	for i := 1; i < nodes; i += 2 {
+		edges = append(edges, graph.Edge{From: graph.Node(i - 1), To: graph.Node(i)})
+		edges = append(edges, graph.Edge{From: graph.Node(i), To: graph.Node(i - 1)})
+	}
+	return fromEdges(nodes, edges)
+}
+
+func duplicateGraph(nodes int) *graph.Graph {
+	var edges []graph.Edge
+	for i := 1; i < nodes; i++ {
+		e := graph.Edge{From: graph.Node(i / 2), To: graph.Node(i)}
+		for k := 0; k < 3; k++ {
+			edges = append(edges, e)
+		}
+	}
+	return fromEdges(nodes, undirected(edges))
+}
+
+func testGraphs() []testGraph {
+	return []testGraph{
+		{"single", fromEdges(1, nil), []graph.Node{0}},
+		{"empty", fromEdges(100, nil), []graph.Node{0, 50, 99}},
+		{"path", pathGraph(300), []graph.Node{0, 150, 299}},
+		{"star", starGraph(5000), []graph.Node{0, 1, 4999}},
+		{"grid", gridGraph(70, 30), []graph.Node{0, 1234, 2099}},
+		{"disconnected", disconnectedGraph(2000), []graph.Node{0, 1999}},
+		{"self-loops", selfLoopGraph(500), []graph.Node{0, 7, 499}},
+		{"duplicates", duplicateGraph(3000), []graph.Node{0, 1500}},
+		{"random-small", randomGraph(1, 63, 3), []graph.Node{0, 31, 62}},
+		{"random", randomGraph(3, 20000, 8), []graph.Node{0, 777, 19999}},
+	}
+}
+
+func TestVariants(t *testing.T) {
+	for _, tg := range testGraphs() {
+		tg := tg
+		t.Run(tg.Name, func(t *testing.T) {
+			for _, source := range tg.Sources {
+				expected := make([]int, tg.Graph.Order())
+				s00_baseline.BreadthFirst(tg.Graph, source, expected)
+
+				for _, v := range All {
+					procs := procsList
+					if v.Parallel == nil {
+						procs = []int{1}
+					}
+					for _, p := range procs {
+						levels := make([]int, tg.Graph.Order())
+						v.WithProcs(p)(tg.Graph, source, levels)
+						if !reflect.DeepEqual(expected, levels) {
+							t.Errorf("%v %dx from %v: levels differ from baseline", v.Name, p, source)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVariants(b *testing.B) {
+	g, err := graph.LoadText(*dataset)
+	if err != nil {
+		b.Skip(err)
+	}
+
+	const source = 2
+	levels := make([]int, g.Order())
+	s00_baseline.BreadthFirst(g, source, levels)
+	edges := 0
+	for n, level := range levels {
+		if level > 0 {
+			edges += len(g.Neighbors(graph.Node(n)))
+		}
+	}
+
+	for _, v := range All {
+		if v.Has(Skip) {
+			continue
+		}
+		procs := procsList
+		if v.Parallel == nil {
+			procs = []int{1}
+		}
+		for _, p := range procs {
+			name := v.Name
+			if v.Parallel != nil {
+				name = fmt.Sprintf("%v/procs=%d", v.Name, p)
+			}
+			iterate := v.WithProcs(p)
+			b.Run(name, func(b *testing.B) {
+				levels := make([]int, g.Order())
+				var elapsed time.Duration
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					for k := range levels {
+						levels[k] = 0
+					}
+					b.StartTimer()
+
+					start := time.Now()
+					iterate(g, source, levels)
+					elapsed += time.Since(start)
+				}
+				b.ReportMetric(float64(edges)*float64(b.N)/elapsed.Seconds()/1e6, "MTEPS")
+			})
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	s00_baseline "github.com/egonelbre/a-tale-of-bfs/00_baseline"
+	"github.com/egonelbre/a-tale-of-bfs/graph"
+)
+
+// Oracle verifies levels against the 00_baseline search.
+//
+// The first check of a source compares the levels node by node, afterwards
+// only a hash of the expected levels is kept per source, so that checking many
+// sources doesn't hold their levels in memory. When the hash differs, the
+// baseline of that source is recomputed to describe the differing nodes.
+type Oracle struct {
+	Graph  *graph.Graph
+	hashes map[graph.Node]uint64
+}
+
+func NewOracle(g *graph.Graph) *Oracle {
+	return &Oracle{Graph: g, hashes: map[graph.Node]uint64{}}
+}
+
+func (oracle *Oracle) baseline(source graph.Node) []int {
+	levels := make([]int, oracle.Graph.Order())
+	s00_baseline.BreadthFirst(oracle.Graph, source, levels)
+	return levels
+}
+
+// Check returns an error describing the first nodes whose level differs from the baseline.
+func (oracle *Oracle) Check(source graph.Node, levels []int) error {
+	if expected, ok := oracle.hashes[source]; ok && expected == hashLevels(levels) {
+		return nil
+	}
+
+	expected := oracle.baseline(source)
+	oracle.hashes[source] = hashLevels(expected)
+	return diffLevels(levels, expected)
+}
+
+// Run runs iterate and checks the result, it fails when iterate panics
+// or does not finish in timeout.
+func (oracle *Oracle) Run(source graph.Node, iterate IterateFn, timeout time.Duration) error {
+	levels := make([]int, oracle.Graph.Order())
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		iterate(oracle.Graph, source, levels)
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-time.After(timeout):
+		return fmt.Errorf("locked, did not finish in %v", timeout)
+	}
+
+	return oracle.Check(source, levels)
+}
+
+func hashLevels(levels []int) uint64 {
+	// FNV-1a over the level values
+	hash := uint64(14695981039346656037)
+	for _, level := range levels {
+		hash ^= uint64(level)
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+const maxReportedMismatches = 5
+
+func diffLevels(levels, expected []int) error {
+	if len(levels) != len(expected) {
+		return fmt.Errorf("got %d levels, expected %d", len(levels), len(expected))
+	}
+
+	var mismatches []string
+	count := 0
+	for node := range levels {
+		if levels[node] != expected[node] {
+			if count < maxReportedMismatches {
+				mismatches = append(mismatches,
+					fmt.Sprintf("node %d level %d exp %d", node, levels[node], expected[node]))
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	if count > maxReportedMismatches {
+		mismatches = append(mismatches, fmt.Sprintf("... %d nodes differ", count))
+	}
+	return fmt.Errorf("%s", strings.Join(mismatches, ", "))
+}